@@ -0,0 +1,483 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"network-toolkit/network"
+)
+
+// Exit codes returned by the non-interactive CLI
+const (
+	exitOK    = 0 // Command completed successfully
+	exitError = 1 // Command failed while running
+	exitUsage = 2 // Invalid command line (unknown command, bad flag or value)
+
+	exitInterrupted = 130 // Scan stopped by Ctrl+C/SIGTERM (partial results were printed)
+)
+
+// errUsage marks errors caused by an invalid command line
+var errUsage = errors.New("usage error")
+
+// errInterrupted is returned when a scan was stopped by a signal
+var errInterrupted = errors.New("scan interrupted, partial results shown")
+
+// errBadFlags is returned when the flag package has already reported the problem
+var errBadFlags = fmt.Errorf("%w: invalid flags", errUsage)
+
+// cliCommand describes a non-interactive subcommand
+type cliCommand struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+// cliCommands lists the subcommands in the order shown by the usage text
+var cliCommands = []cliCommand{
+	{"listen", "List listening ports (netstat -tuln)", runListenCommand},
+	{"scan", "Network scanner over a CIDR range (nmap -sS -sV)", runScanCommand},
+	{"stealth", "Stealth single-host scanner (nmap -sS -sV -p- -T4)", runStealthCommand},
+}
+
+// runCLI executes a subcommand and returns the process exit code
+func runCLI(args []string) int {
+	name := args[0]
+
+	switch name {
+	case "help", "-h", "-help", "--help":
+		printUsage(os.Stdout)
+		return exitOK
+	case "version", "-version", "--version":
+		fmt.Printf("%s v%s\n", appName, appVersion)
+		return exitOK
+	}
+
+	for _, cmd := range cliCommands {
+		if cmd.name != name {
+			continue
+		}
+
+		err := cmd.run(args[1:])
+		switch {
+		case err == nil:
+			return exitOK
+		case errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.Is(err, errBadFlags):
+			return exitUsage
+		case errors.Is(err, errInterrupted):
+			fmt.Fprintf(os.Stderr, "%s %s: %v\n", appName, name, err)
+			return exitInterrupted
+		case errors.Is(err, errUsage):
+			fmt.Fprintf(os.Stderr, "%s %s: %v\n", appName, name, err)
+			return exitUsage
+		default:
+			fmt.Fprintf(os.Stderr, "%s %s: %v\n", appName, name, err)
+			return exitError
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: unknown command %q\n\n", appName, name)
+	printUsage(os.Stderr)
+	return exitUsage
+}
+
+// printUsage prints the list of available subcommands
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s [command] [flags]\n\n", appName)
+	fmt.Fprintln(w, "Without a command the interactive menu is started.")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range cliCommands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "  %-10s %s\n", "version", "Print the version and exit")
+	fmt.Fprintf(w, "  %-10s %s\n", "help", "Show this help")
+	fmt.Fprintf(w, "\nRun '%s <command> -h' for the flags of a command.\n", appName)
+}
+
+// newFlagSet creates a flag set that reports errors instead of exiting
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s\n\nFlags:\n", appName, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args and wraps flag errors as usage errors
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		// The flag package already printed the error and the usage
+		return errBadFlags
+	}
+	return nil
+}
+
+// usageErrorf builds an error reported with the usage exit code
+func usageErrorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
+
+// interruptContext returns a context cancelled on Ctrl+C or SIGTERM, so a
+// running scan can stop and report what it collected so far
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// outputOptions holds the flags shared by every command that writes a report
+type outputOptions struct {
+	formatName string
+	path       string
+	format     network.OutputFormat
+	supported  []network.OutputFormat
+}
+
+// addOutputFlags registers -format and -output on fs, accepting the formats
+// the command can write
+func addOutputFlags(fs *flag.FlagSet, supported []network.OutputFormat) *outputOptions {
+	names := make([]string, 0, len(supported))
+	for _, format := range supported {
+		names = append(names, string(format))
+	}
+
+	opts := &outputOptions{supported: supported}
+	fs.StringVar(&opts.formatName, "format", string(network.FormatText), "report format: "+strings.Join(names, ", "))
+	fs.StringVar(&opts.path, "output", "", "write the report to this file instead of stdout")
+	return opts
+}
+
+// validate checks the requested format before any scan starts
+func (o *outputOptions) validate() error {
+	format, err := network.ParseOutputFormat(o.formatName)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	names := make([]string, 0, len(o.supported))
+	for _, supported := range o.supported {
+		if supported == format {
+			o.format = format
+			return nil
+		}
+		names = append(names, string(supported))
+	}
+	return usageErrorf("output format %q is not supported by this command (supported: %s)", format, strings.Join(names, ", "))
+}
+
+// observer returns the console observer for scan progress. Progress goes
+// to stdout only when it cannot be mixed with a machine-readable report.
+func (o *outputOptions) observer() network.ScanObserver {
+	if o.path == "" && o.format != network.FormatText {
+		return network.NewConsoleObserver(os.Stderr)
+	}
+	return network.NewConsoleObserver(os.Stdout)
+}
+
+// write sends the report to stdout or to the -output file
+func (o *outputOptions) write(report func(w io.Writer, format network.OutputFormat) error) error {
+	if o.path == "" {
+		return report(os.Stdout, o.format)
+	}
+
+	file, err := os.Create(o.path)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %v", err)
+	}
+
+	if err := report(file, o.format); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing report: %v", err)
+	}
+	return file.Close()
+}
+
+// flagSet reports whether the named flag was given on the command line
+func flagSet(fs *flag.FlagSet, name string) bool {
+	found := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			found = true
+		}
+	})
+	return found
+}
+
+// targetArg returns the target given either by flag or as the single positional argument
+func targetArg(fs *flag.FlagSet, flagValue string) (string, error) {
+	target := strings.TrimSpace(flagValue)
+	switch {
+	case fs.NArg() == 0:
+		return target, nil
+	case target != "" || fs.NArg() > 1:
+		return "", usageErrorf("unexpected argument %q", fs.Arg(fs.NArg()-1))
+	default:
+		return strings.TrimSpace(fs.Arg(0)), nil
+	}
+}
+
+// runListenCommand implements "listen"
+func runListenCommand(args []string) error {
+	fs := newFlagSet("listen", "listen [flags]")
+	output := addOutputFlags(fs, network.ListeningPortsFormats())
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageErrorf("unexpected argument %q", fs.Arg(0))
+	}
+	if err := output.validate(); err != nil {
+		return err
+	}
+
+	ports, err := network.ListListeningPorts()
+	if err != nil {
+		return err
+	}
+
+	return output.write(func(w io.Writer, format network.OutputFormat) error {
+		return network.WriteListeningPorts(w, format, ports)
+	})
+}
+
+// runScanCommand implements "scan", mapping flags onto NetworkScanConfig
+func runScanCommand(args []string) error {
+	fs := newFlagSet("scan", "scan [flags] [target...]")
+	networkFlag := fs.String("network", "", "targets: comma-separated IPs, CIDRs (192.168.1.0/24, 2001:db8::/120), ranges (10.0.0.1-50) and hostnames")
+	exclude := fs.String("exclude", "", "targets to skip, same syntax as -network")
+	targetFile := fs.String("target-file", "", `read targets from a file, one per line ("-" for stdin, '#' starts a comment)`)
+	excludeFile := fs.String("exclude-file", "", `read targets to skip from a file ("-" for stdin)`)
+	ports := fs.String("ports", "all", `ports to scan: lists and ranges (22,80,8000-8100), protocol prefixes (T:80,U:53), named sets (common, top100, top1000, well-known) and exclusions (!25)`)
+	timeout := fs.Duration("timeout", 0, "timeout per port (default: the -timing template's, 2s for T3)")
+	threads := fs.Int("threads", 0, "number of parallel threads per host, 1-100 (default: the -timing template's, 10 for T3)")
+	timing := fs.String("timing", "T3", "timing template: T0 (paranoid) to T5 (insane), setting parallelism, timeouts, probe delay and retransmissions")
+	serviceDetection := fs.Bool("service-detection", true, "grab banners to identify services")
+	osDetection := fs.Bool("os-detection", false, "guess the operating system from TTL, service banners and open ports")
+	topPorts := fs.Int("top-ports", 0, "scan the N ports most likely to be open (same as -ports topN)")
+	protocol := fs.String("protocol", "tcp", "protocol to scan: tcp, udp or both")
+	discovery := fs.String("discovery", strings.Join(network.DefaultDiscovery, ","), "host discovery methods, combined: arp, icmp-echo, icmp-timestamp, tcp-syn, tcp-ack, udp")
+	discoveryPorts := fs.String("discovery-ports", "", "ports of the TCP and UDP pings, with T:/U: prefixes (default T:80,443,22,21,25,3389,U:40125)")
+	skipDiscovery := fs.Bool("Pn", false, "treat every target as up and skip host discovery")
+	pingOnly := fs.Bool("sn", false, "ping sweep: list the live hosts with latency, MAC and vendor, without scanning ports")
+	output := addOutputFlags(fs, network.OutputFormats())
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := output.validate(); err != nil {
+		return err
+	}
+
+	target := strings.TrimSpace(*networkFlag)
+	if fs.NArg() > 0 {
+		if target != "" {
+			return usageErrorf("unexpected argument %q", fs.Arg(0))
+		}
+		target = strings.Join(fs.Args(), ",")
+	}
+	if target == "" && *targetFile == "" {
+		return usageErrorf("a target is required (-network, -target-file or positional targets)")
+	}
+	if *targetFile == "-" && *excludeFile == "-" {
+		return usageErrorf("-target-file and -exclude-file cannot both read stdin")
+	}
+	if flagSet(fs, "threads") && (*threads < 1 || *threads > 100) {
+		return usageErrorf("threads must be between 1 and 100, got %d", *threads)
+	}
+	if flagSet(fs, "timeout") && *timeout <= 0 {
+		return usageErrorf("timeout must be positive, got %v", *timeout)
+	}
+	if _, err := network.ParseTiming(*timing); err != nil {
+		return usageErrorf("%v", err)
+	}
+	if *pingOnly && *skipDiscovery {
+		return usageErrorf("-sn and -Pn cannot be combined")
+	}
+	if *topPorts != 0 {
+		if flagSet(fs, "ports") {
+			return usageErrorf("-ports and -top-ports cannot be combined")
+		}
+		if *topPorts < 0 || *topPorts > 65535 {
+			return usageErrorf("top-ports must be between 1 and 65535, got %d", *topPorts)
+		}
+		*ports = fmt.Sprintf("top%d", *topPorts)
+	}
+	portSpec, err := network.ParsePortSpec(*ports)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	scanProtocol, err := network.ParseProtocol(*protocol)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	if scanProtocol != network.ProtocolBoth && len(portSpec.For(scanProtocol)) == 0 {
+		return usageErrorf("port specification %q selects no %s ports", *ports, scanProtocol)
+	}
+	discoveryMethods, err := network.ParseDiscovery(*discovery)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	if len(discoveryMethods) == 0 {
+		return usageErrorf("discovery needs at least one method")
+	}
+	var pingPorts network.PortSpec
+	if strings.TrimSpace(*discoveryPorts) != "" {
+		if pingPorts, err = network.ParsePortSpec(*discoveryPorts); err != nil {
+			return usageErrorf("discovery-ports: %v", err)
+		}
+	}
+
+	var targetList, excludeList []string
+	if *targetFile != "" {
+		if targetList, err = network.ReadTargetFile(*targetFile); err != nil {
+			return err
+		}
+	}
+	if *excludeFile != "" {
+		if excludeList, err = network.ReadTargetFile(*excludeFile); err != nil {
+			return err
+		}
+	}
+
+	config := network.NetworkScanConfig{
+		Network:           target,
+		Exclude:           *exclude,
+		TargetList:        targetList,
+		ExcludeList:       excludeList,
+		PortRange:         *ports,
+		Timeout:           *timeout,
+		Threads:           *threads,
+		Timing:            *timing,
+		ServiceDetection:  *serviceDetection,
+		OSDetection:       *osDetection,
+		Protocol:          *protocol,
+		Discovery:         discoveryMethods,
+		DiscoveryTCPPorts: pingPorts.TCP,
+		DiscoveryUDPPorts: pingPorts.UDP,
+		SkipDiscovery:     *skipDiscovery,
+		PingOnly:          *pingOnly,
+		Observer:          output.observer(),
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	report, err := network.ScanNetworkReportContext(ctx, config)
+	if err != nil && report == nil {
+		return err
+	}
+
+	err = output.write(func(w io.Writer, format network.OutputFormat) error {
+		return network.WriteNetworkScan(w, format, report)
+	})
+	if err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		// Formats without a place for it (CSV) rely on this message
+		return fmt.Errorf("%w (%d of %d hosts scanned)", errInterrupted, report.ScannedHosts, report.TotalHosts)
+	}
+	return nil
+}
+
+// runStealthCommand implements "stealth", mapping flags onto StealthyScanConfig
+func runStealthCommand(args []string) error {
+	fs := newFlagSet("stealth", "stealth [flags] [IP]")
+	targetFlag := fs.String("target", "", "target IPv4 or IPv6 address (e.g., 192.168.1.20, fe80::1%eth0)")
+	startPort := fs.Int("start-port", 1, "first port of the range")
+	endPort := fs.Int("end-port", 1024, "last port of the range")
+	timeout := fs.Duration("timeout", 0, "timeout per port (default: the -timing template's, 1s for T4)")
+	threads := fs.Int("threads", 0, "number of parallel threads, 1-200 (default: the -timing template's, 50 for T4)")
+	serviceDetection := fs.Bool("service-detection", true, "grab banners to detect service versions")
+	timing := fs.String("timing", "T4", "timing template: T0 (paranoid) to T5 (insane), setting parallelism, timeouts, probe delay and retransmissions")
+	aggressive := fs.Bool("aggressive", true, "deprecated: use -timing (false selects T3)")
+	protocol := fs.String("protocol", "tcp", "protocol to scan: tcp, udp or both")
+	scanType := fs.String("scan-type", network.ScanTypeSYN, "TCP technique: syn, fin, null, xmas, ack or window (raw, need root; fall back to connect) or connect")
+	output := addOutputFlags(fs, network.OutputFormats())
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := output.validate(); err != nil {
+		return err
+	}
+
+	target, err := targetArg(fs, *targetFlag)
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		return usageErrorf("a target IP is required (-target or positional IP)")
+	}
+	if _, err := netip.ParseAddr(target); err != nil {
+		return usageErrorf("invalid target IP %q", target)
+	}
+	if *startPort < 1 || *startPort > 65535 {
+		return usageErrorf("start-port must be between 1 and 65535, got %d", *startPort)
+	}
+	if *endPort < *startPort || *endPort > 65535 {
+		return usageErrorf("end-port must be between %d and 65535, got %d", *startPort, *endPort)
+	}
+	if flagSet(fs, "threads") && (*threads < 1 || *threads > 200) {
+		return usageErrorf("threads must be between 1 and 200, got %d", *threads)
+	}
+	if flagSet(fs, "timeout") && *timeout <= 0 {
+		return usageErrorf("timeout must be positive, got %v", *timeout)
+	}
+	if flagSet(fs, "aggressive") && !flagSet(fs, "timing") && !*aggressive {
+		*timing = "T3"
+	}
+	if _, err := network.ParseTiming(*timing); err != nil {
+		return usageErrorf("%v", err)
+	}
+	if _, err := network.ParseProtocol(*protocol); err != nil {
+		return usageErrorf("%v", err)
+	}
+	if _, err := network.ParseScanType(*scanType); err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	config := network.StealthyScanConfig{
+		TargetIP:         target,
+		StartPort:        *startPort,
+		EndPort:          *endPort,
+		Timeout:          *timeout,
+		Threads:          *threads,
+		ServiceDetection: *serviceDetection,
+		Timing:           *timing,
+		Protocol:         *protocol,
+		ScanType:         *scanType,
+		Observer:         output.observer(),
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	report, err := network.ScanHostStealthyContext(ctx, config)
+	if err != nil && report == nil {
+		return err
+	}
+
+	err = output.write(func(w io.Writer, format network.OutputFormat) error {
+		return network.WriteStealthyScan(w, format, report)
+	})
+	if err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return errInterrupted
+	}
+	return nil
+}
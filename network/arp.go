@@ -0,0 +1,89 @@
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// errNotOnLink is returned by ARP pings to addresses outside the directly
+// attached IPv4 networks
+var errNotOnLink = errors.New("address is not on a directly attached network")
+
+// ARP constants (RFC 826) for Ethernet and IPv4
+const (
+	arpRequest    = 1
+	arpReply      = 2
+	etherTypeARP  = 0x0806
+	etherTypeIPv4 = 0x0800
+	arpPacketLen  = 28
+)
+
+// arpResult is the answer to an ARP ping
+type arpResult struct {
+	MAC string
+	RTT time.Duration
+}
+
+// onLinkInterface returns the Ethernet interface whose IPv4 network holds
+// addr, with the interface's address on that network
+func onLinkInterface(addr netip.Addr) (*net.Interface, netip.Addr, error) {
+	addr = addr.Unmap()
+	if !addr.Is4() {
+		return nil, netip.Addr{}, errNotOnLink
+	}
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, netip.Addr{}, err
+	}
+	for i := range interfaces {
+		iface := &interfaces[i]
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) != 6 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, ifaceAddr := range addrs {
+			ipNet, ok := ifaceAddr.(*net.IPNet)
+			if !ok || ipNet.IP.To4() == nil || !ipNet.Contains(addr.AsSlice()) {
+				continue
+			}
+			src, _ := netip.AddrFromSlice(ipNet.IP.To4())
+			return iface, src, nil
+		}
+	}
+	return nil, netip.Addr{}, errNotOnLink
+}
+
+// arpRequestPacket builds a request asking who has target, from the
+// interface's MAC and IPv4 address
+func arpRequestPacket(mac net.HardwareAddr, src, target netip.Addr) []byte {
+	packet := make([]byte, arpPacketLen)
+	binary.BigEndian.PutUint16(packet[0:], 1) // Ethernet
+	binary.BigEndian.PutUint16(packet[2:], etherTypeIPv4)
+	packet[4], packet[5] = 6, 4
+	binary.BigEndian.PutUint16(packet[6:], arpRequest)
+	copy(packet[8:14], mac)
+	s, t := src.As4(), target.As4()
+	copy(packet[14:18], s[:])
+	copy(packet[24:28], t[:])
+	return packet
+}
+
+// parseARPReply returns the MAC announced by an ARP reply from target
+func parseARPReply(packet []byte, target netip.Addr) (net.HardwareAddr, bool) {
+	if len(packet) < arpPacketLen || binary.BigEndian.Uint16(packet[6:]) != arpReply ||
+		packet[4] != 6 || packet[5] != 4 {
+		return nil, false
+	}
+	t := target.As4()
+	if !bytes.Equal(packet[14:18], t[:]) {
+		return nil, false
+	}
+	return net.HardwareAddr(bytes.Clone(packet[8:14])), true
+}
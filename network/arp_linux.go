@@ -0,0 +1,70 @@
+//go:build linux
+
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// htons converts a 16-bit value to network byte order for packet sockets
+func htons(value uint16) uint16 {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], value)
+	return binary.NativeEndian.Uint16(b[:])
+}
+
+// arpPing asks who has addr on the link it belongs to, over a packet
+// socket (CAP_NET_RAW), and returns the MAC address that answered
+func arpPing(ctx context.Context, addr netip.Addr, timeout time.Duration) (arpResult, error) {
+	addr = addr.Unmap()
+	iface, src, err := onLinkInterface(addr)
+	if err != nil {
+		return arpResult{}, err
+	}
+
+	protocol := htons(etherTypeARP)
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, int(protocol))
+	if err != nil {
+		return arpResult{}, fmt.Errorf("opening packet socket (needs CAP_NET_RAW): %v", err)
+	}
+	defer syscall.Close(fd)
+
+	if err := syscall.Bind(fd, &syscall.SockaddrLinklayer{Protocol: protocol, Ifindex: iface.Index}); err != nil {
+		return arpResult{}, fmt.Errorf("binding packet socket to %s: %v", iface.Name, err)
+	}
+	tv := syscall.NsecToTimeval(icmpPollInterval.Nanoseconds())
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		return arpResult{}, fmt.Errorf("configuring packet socket: %v", err)
+	}
+
+	broadcast := &syscall.SockaddrLinklayer{Protocol: protocol, Ifindex: iface.Index, Halen: 6}
+	copy(broadcast.Addr[:], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	start := time.Now()
+	if err := syscall.Sendto(fd, arpRequestPacket(iface.HardwareAddr, src, addr), 0, broadcast); err != nil {
+		return arpResult{}, fmt.Errorf("sending ARP request: %v", err)
+	}
+
+	buffer := make([]byte, 128)
+	for deadline := start.Add(timeout); time.Now().Before(deadline); {
+		if ctx.Err() != nil {
+			return arpResult{}, ctx.Err()
+		}
+		n, _, err := syscall.Recvfrom(fd, buffer, 0)
+		if err == syscall.EAGAIN || err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return arpResult{}, err
+		}
+		if mac, ok := parseARPReply(buffer[:n], addr); ok {
+			return arpResult{MAC: mac.String(), RTT: time.Since(start)}, nil
+		}
+	}
+	return arpResult{}, errors.New("no ARP reply")
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package network
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"time"
+)
+
+// arpPing is only implemented on Linux
+func arpPing(ctx context.Context, addr netip.Addr, timeout time.Duration) (arpResult, error) {
+	return arpResult{}, errors.New("ARP pings are not supported on this system")
+}
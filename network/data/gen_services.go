@@ -0,0 +1,133 @@
+//go:build ignore
+
+// gen_services converts the IANA Service Name and Transport Protocol Port
+// Number Registry CSV export
+// (https://www.iana.org/assignments/service-names-port-numbers/service-names-port-numbers.csv)
+// to the services.txt format embedded by the network package.
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// entry is one port/protocol assignment
+type entry struct {
+	name, protocol, description string
+	port                        int
+}
+
+func main() {
+	in := flag.String("in", "service-names-port-numbers.csv", "IANA registry CSV export")
+	out := flag.String("out", "data/services.txt", "file to write")
+	flag.Parse()
+
+	file, err := os.Open(*in)
+	if err != nil {
+		log.Fatalf("error opening registry: %v", err)
+	}
+	defer file.Close()
+
+	entries, err := readRegistry(file)
+	if err != nil {
+		log.Fatalf("error reading registry: %v", err)
+	}
+
+	output, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("error creating %s: %v", *out, err)
+	}
+	defer output.Close()
+
+	fmt.Fprintln(output, "# Port and protocol service registry for network-toolkit:")
+	fmt.Fprintln(output, "# <service> <port>/<protocol> [# description]")
+	fmt.Fprintln(output, "#")
+	fmt.Fprintln(output, "# Generated by data/gen_services.go from the IANA Service Name and Transport")
+	fmt.Fprintln(output, "# Protocol Port Number Registry. Do not edit.")
+	for _, e := range entries {
+		line := fmt.Sprintf("%s\t%d/%s", e.name, e.port, e.protocol)
+		if e.description != "" {
+			line += "\t# " + e.description
+		}
+		fmt.Fprintln(output, line)
+	}
+	log.Printf("wrote %d services to %s", len(entries), *out)
+}
+
+// readRegistry returns the first named TCP and UDP assignment of each port,
+// sorted by port and protocol. Port ranges are expanded.
+func readRegistry(r io.Reader) ([]entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"Service Name", "Port Number", "Transport Protocol", "Description"} {
+		if _, exists := columns[name]; !exists {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	seen := map[string]bool{}
+	var entries []entry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(name string) string {
+			if i := columns[name]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		name, protocol := field("Service Name"), strings.ToLower(field("Transport Protocol"))
+		if name == "" || (protocol != "tcp" && protocol != "udp") {
+			continue
+		}
+		from, to, isRange := strings.Cut(field("Port Number"), "-")
+		if !isRange {
+			to = from
+		}
+		start, err1 := strconv.Atoi(from)
+		end, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		description := strings.Join(strings.Fields(field("Description")), " ")
+		for port := start; port <= end; port++ {
+			key := fmt.Sprintf("%d/%s", port, protocol)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			entries = append(entries, entry{name: name, protocol: protocol, description: description, port: port})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].port != entries[j].port {
+			return entries[i].port < entries[j].port
+		}
+		return entries[i].protocol < entries[j].protocol
+	})
+	return entries, nil
+}
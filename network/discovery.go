@@ -0,0 +1,251 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Host discovery methods accepted by NetworkScanConfig.Discovery
+const (
+	DiscoveryARP           = "arp"            // ARP request on directly attached IPv4 networks (nmap -PR)
+	DiscoveryICMPEcho      = "icmp-echo"      // ICMP echo request (nmap -PE)
+	DiscoveryICMPTimestamp = "icmp-timestamp" // ICMP timestamp request, IPv4 (nmap -PP)
+	DiscoveryTCPSYN        = "tcp-syn"        // TCP SYN to the discovery ports (nmap -PS)
+	DiscoveryTCPACK        = "tcp-ack"        // TCP ACK to the discovery ports (nmap -PA)
+	DiscoveryUDP           = "udp"            // UDP datagram to the discovery ports (nmap -PU)
+)
+
+// DefaultDiscovery are the methods used when a config does not select any,
+// like nmap's default ping
+var DefaultDiscovery = []string{DiscoveryARP, DiscoveryICMPEcho, DiscoveryTCPSYN, DiscoveryTCPACK, DiscoveryICMPTimestamp}
+
+// DefaultDiscoveryTCPPorts are pinged by the TCP methods when a config does
+// not set DiscoveryTCPPorts
+var DefaultDiscoveryTCPPorts = []int{80, 443, 22, 21, 25, 3389}
+
+// DefaultDiscoveryUDPPorts are pinged by the UDP method when a config does
+// not set DiscoveryUDPPorts: a port unlikely to be open, which draws an
+// ICMP port-unreachable
+var DefaultDiscoveryUDPPorts = []int{40125}
+
+// HostDiscovery tells how a host was found up
+type HostDiscovery struct {
+	Method  string        `json:"method,omitempty"` // Discovery method that got an answer, empty when discovery was skipped
+	Reason  string        `json:"reason"`           // nmap-style reason (echo-reply, syn-ack on 443, arp-response, ...)
+	Latency time.Duration `json:"-"`                // Exported as latency_ms
+	TTL     int           `json:"ttl,omitempty"`    // TTL of the answer, when known
+	MAC     string        `json:"mac,omitempty"`    // Set by ARP
+}
+
+// ParseDiscovery parses a comma-separated list of discovery methods
+func ParseDiscovery(spec string) ([]string, error) {
+	var methods []string
+	for _, field := range strings.Split(spec, ",") {
+		method := strings.ToLower(strings.TrimSpace(field))
+		if method == "" {
+			continue
+		}
+		switch method {
+		case DiscoveryARP, DiscoveryICMPEcho, DiscoveryICMPTimestamp, DiscoveryTCPSYN, DiscoveryTCPACK, DiscoveryUDP:
+			methods = append(methods, method)
+		default:
+			return nil, fmt.Errorf("unknown discovery method %q (supported: arp, icmp-echo, icmp-timestamp, tcp-syn, tcp-ack, udp)", field)
+		}
+	}
+	return methods, nil
+}
+
+// DiscoverHost tells whether ip is up with the discovery methods of config,
+// using its discovery ports and timeout. The methods run together and the
+// first answer wins; nil means no method got one. Methods needing raw
+// sockets degrade without CAP_NET_RAW: TCP pings fall back to connects,
+// ICMP echoes to unprivileged ping sockets, while ARP and ICMP timestamps
+// are skipped.
+func DiscoverHost(ctx context.Context, ip string, config NetworkScanConfig) *HostDiscovery {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil
+	}
+	methods := config.Discovery
+	if len(methods) == 0 {
+		methods = DefaultDiscovery
+	}
+	tcpPorts := config.DiscoveryTCPPorts
+	if len(tcpPorts) == 0 {
+		tcpPorts = DefaultDiscoveryTCPPorts
+	}
+	udpPorts := config.DiscoveryUDPPorts
+	if len(udpPorts) == 0 {
+		udpPorts = DefaultDiscoveryUDPPorts
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	pinger := &hostPinger{ip: ip, addr: addr.Unmap(), timeout: config.Timeout}
+
+	var probes []func() *HostDiscovery
+	for _, method := range methods {
+		method := method
+		switch method {
+		case DiscoveryARP:
+			probes = append(probes, func() *HostDiscovery { return pinger.arp(ctx) })
+		case DiscoveryICMPEcho:
+			probes = append(probes, func() *HostDiscovery { return pinger.icmp(ctx, method, "echo-reply", icmpEcho) })
+		case DiscoveryICMPTimestamp:
+			probes = append(probes, func() *HostDiscovery { return pinger.icmp(ctx, method, "timestamp-reply", icmpTimestamp) })
+		case DiscoveryTCPSYN, DiscoveryTCPACK:
+			// Without raw sockets both degrade to the same connects
+			if method == DiscoveryTCPACK && containsName(methods, DiscoveryTCPSYN) && pinger.prober(DiscoveryTCPACK) == nil {
+				continue
+			}
+			for _, port := range tcpPorts {
+				port := port
+				probes = append(probes, func() *HostDiscovery { return pinger.tcp(ctx, method, port) })
+			}
+		case DiscoveryUDP:
+			for _, port := range udpPorts {
+				port := port
+				probes = append(probes, func() *HostDiscovery { return pinger.udp(ctx, port) })
+			}
+		}
+	}
+
+	answers := make(chan *HostDiscovery, len(probes))
+	var wg sync.WaitGroup
+	for _, probe := range probes {
+		probe := probe
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			answers <- probe()
+		}()
+	}
+	go func() {
+		// The raw sockets stay open until the last probe is done
+		wg.Wait()
+		pinger.close()
+		close(answers)
+	}()
+
+	for answer := range answers {
+		if answer != nil {
+			// The other probes stop on cancel and drain into the buffer
+			return answer
+		}
+	}
+	return nil
+}
+
+// hostPinger sends the discovery probes to one host, sharing the raw
+// sockets of the TCP pings between the ports
+type hostPinger struct {
+	ip      string
+	addr    netip.Addr
+	timeout time.Duration
+
+	mu      sync.Mutex
+	probers map[string]*RawProber // By method, nil when raw sockets are unavailable
+}
+
+// prober returns the raw prober of a TCP method, opened on first use
+func (p *hostPinger) prober(method string) *RawProber {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if prober, opened := p.probers[method]; opened {
+		return prober
+	}
+	if p.probers == nil {
+		p.probers = map[string]*RawProber{}
+	}
+	scanType := ScanTypeSYN
+	if method == DiscoveryTCPACK {
+		scanType = ScanTypeACK
+	}
+	prober, err := NewRawProber(p.ip, scanType, p.timeout)
+	if err != nil {
+		prober = nil
+	} else {
+		prober.Retries = 0
+	}
+	p.probers[method] = prober
+	return prober
+}
+
+// close releases the raw sockets
+func (p *hostPinger) close() {
+	for _, prober := range p.probers {
+		if prober != nil {
+			prober.Close()
+		}
+	}
+}
+
+// arp resolves the host's MAC on the local link
+func (p *hostPinger) arp(ctx context.Context) *HostDiscovery {
+	result, err := arpPing(ctx, p.addr, p.timeout)
+	if err != nil {
+		return nil
+	}
+	return &HostDiscovery{Method: DiscoveryARP, Reason: "arp-response", Latency: result.RTT, MAC: result.MAC}
+}
+
+// icmp sends one ICMP request with ping
+func (p *hostPinger) icmp(ctx context.Context, method, reason string, ping func(context.Context, netip.Addr, time.Duration) (icmpReply, error)) *HostDiscovery {
+	reply, err := ping(ctx, p.addr, p.timeout)
+	if err != nil {
+		return nil
+	}
+	return &HostDiscovery{Method: method, Reason: reason, Latency: reply.RTT, TTL: reply.TTL}
+}
+
+// tcp pings a port: any answer, SYN-ACK or RST, shows the host is up
+func (p *hostPinger) tcp(ctx context.Context, method string, port int) *HostDiscovery {
+	var result PortResult
+	if prober := p.prober(method); prober != nil {
+		result = prober.Probe(ctx, p.ip, port)
+	} else {
+		result = ConnectProber{Timeout: p.timeout}.Probe(ctx, p.ip, port)
+	}
+	switch result.Reason {
+	case "syn-ack", "reset", "conn-refused":
+		return &HostDiscovery{Method: method, Reason: fmt.Sprintf("%s on %d", result.Reason, port),
+			Latency: result.ResponseTime, TTL: result.TTL}
+	}
+	return nil
+}
+
+// udp pings a port: a reply or an ICMP port-unreachable shows the host is up
+func (p *hostPinger) udp(ctx context.Context, port int) *HostDiscovery {
+	result := UDPProber{Timeout: p.timeout}.Probe(ctx, p.ip, port)
+	if result.State != StateOpen && result.State != StateClosed {
+		return nil
+	}
+	return &HostDiscovery{Method: DiscoveryUDP, Reason: fmt.Sprintf("%s on %d", result.Reason, port),
+		Latency: result.ResponseTime}
+}
+
+// hostMAC returns the MAC address of a live host on a directly attached
+// link: the one of the ARP reply, else the neighbour table entry the
+// discovery probes left behind. Routed hosts have none.
+func hostMAC(ip string, discovery *HostDiscovery) string {
+	if discovery != nil && discovery.MAC != "" {
+		return discovery.MAC
+	}
+	neighbors, err := Neighbors()
+	if err != nil {
+		return ""
+	}
+	return neighborMAC(neighbors, ip)
+}
+
+// discoverySummary describes how a host was found up for the text reports
+func discoverySummary(discovery *HostDiscovery) string {
+	if discovery.Method == "" {
+		return discovery.Reason + " (host discovery skipped)"
+	}
+	return fmt.Sprintf("%s (%s, %v latency)", discovery.Reason, discovery.Method, discovery.Latency.Round(time.Microsecond))
+}
@@ -0,0 +1,177 @@
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseDiscovery(t *testing.T) {
+	got, err := ParseDiscovery(" ICMP-echo, tcp-syn,,udp ")
+	if want := []string{DiscoveryICMPEcho, DiscoveryTCPSYN, DiscoveryUDP}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDiscovery = %v, %v; want %v", got, err, want)
+	}
+	if _, err := ParseDiscovery("icmp-echo,traceroute"); err == nil {
+		t.Error("ParseDiscovery accepted an unknown method")
+	}
+	if _, err := ScanNetworkReportContext(context.Background(), NetworkScanConfig{Network: "127.0.0.1", PortRange: "80", Discovery: []string{"ping"}}); err == nil {
+		t.Error("ScanNetworkReportContext accepted an unknown discovery method")
+	}
+}
+
+func TestICMPTimestampMessage(t *testing.T) {
+	now := time.Date(2026, 1, 8, 10, 0, 0, 0, time.UTC)
+	message := icmpTimestampMessage(7, 9, now)
+	if len(message) != 20 || message[0] != icmpTimestampRequest || icmpChecksum(message) != 0 {
+		t.Fatalf("timestamp request %x, want type 13 with a valid checksum", message)
+	}
+	if originate := binary.BigEndian.Uint32(message[8:]); originate != 10*3600*1000 {
+		t.Errorf("originate timestamp = %d, want milliseconds since midnight UTC", originate)
+	}
+}
+
+func TestARPPackets(t *testing.T) {
+	mac := net.HardwareAddr{0x02, 0, 0, 0, 0, 0x01}
+	src, target := netip.MustParseAddr("192.0.2.2"), netip.MustParseAddr("192.0.2.1")
+	request := arpRequestPacket(mac, src, target)
+	if len(request) != arpPacketLen || binary.BigEndian.Uint16(request[6:]) != arpRequest {
+		t.Fatalf("ARP request %x", request)
+	}
+	if _, ok := parseARPReply(request, target); ok {
+		t.Error("parseARPReply accepted a request")
+	}
+
+	// The target answers with its MAC, addressed to the requester
+	reply := make([]byte, arpPacketLen)
+	copy(reply, request[:6])
+	binary.BigEndian.PutUint16(reply[6:], arpReply)
+	copy(reply[8:14], []byte{0x02, 0xfc, 0, 0, 0, 0x05})
+	copy(reply[14:18], request[24:28])
+	copy(reply[18:24], mac)
+	copy(reply[24:28], request[14:18])
+	if got, ok := parseARPReply(reply, target); !ok || got.String() != "02:fc:00:00:00:05" {
+		t.Errorf("parseARPReply = %v, %v; want 02:fc:00:00:00:05", got, ok)
+	}
+	if _, ok := parseARPReply(reply, src); ok {
+		t.Error("parseARPReply accepted a reply from another address")
+	}
+
+	if _, _, err := onLinkInterface(netip.MustParseAddr("127.0.0.1")); err != errNotOnLink {
+		t.Errorf("loopback is on link: %v", err)
+	}
+}
+
+func TestDiscoverHostLoopback(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	// With or without raw sockets the listener answers with a SYN-ACK
+	got := DiscoverHost(context.Background(), "127.0.0.1", NetworkScanConfig{
+		Timeout:           time.Second,
+		Discovery:         []string{DiscoveryTCPSYN},
+		DiscoveryTCPPorts: []int{port},
+	})
+	if got == nil || got.Method != DiscoveryTCPSYN || got.Reason != "syn-ack on "+strconv.Itoa(port) {
+		t.Errorf("TCP SYN ping = %+v, want syn-ack on %d", got, port)
+	}
+
+	// A closed UDP port draws an ICMP port-unreachable
+	got = DiscoverHost(context.Background(), "127.0.0.1", NetworkScanConfig{
+		Timeout:           time.Second,
+		Discovery:         []string{DiscoveryUDP},
+		DiscoveryUDPPorts: []int{port},
+	})
+	if got == nil || got.Reason != "port-unreach on "+strconv.Itoa(port) {
+		t.Errorf("UDP ping = %+v, want port-unreach on %d", got, port)
+	}
+
+	// Loopback is not an Ethernet link
+	if got = DiscoverHost(context.Background(), "127.0.0.1", NetworkScanConfig{Timeout: time.Second, Discovery: []string{DiscoveryARP}}); got != nil {
+		t.Errorf("ARP ping of loopback = %+v, want no answer", got)
+	}
+}
+
+func TestDiscoverHostICMP(t *testing.T) {
+	if _, err := icmpEcho(context.Background(), netip.MustParseAddr("127.0.0.1"), time.Second); err != nil {
+		t.Skipf("ICMP sockets unavailable: %v", err)
+	}
+	got := DiscoverHost(context.Background(), "127.0.0.1", NetworkScanConfig{Timeout: time.Second, Discovery: []string{DiscoveryICMPEcho}})
+	if got == nil || got.Reason != "echo-reply" || got.TTL != 64 {
+		t.Errorf("ICMP echo ping = %+v, want echo-reply with TTL 64", got)
+	}
+
+	if _, err := icmpTimestamp(context.Background(), netip.MustParseAddr("127.0.0.1"), time.Second); err != nil {
+		t.Skipf("raw ICMP sockets unavailable: %v", err)
+	}
+	got = DiscoverHost(context.Background(), "127.0.0.1", NetworkScanConfig{Timeout: time.Second, Discovery: []string{DiscoveryICMPTimestamp}})
+	if got == nil || got.Reason != "timestamp-reply" {
+		t.Errorf("ICMP timestamp ping = %+v, want timestamp-reply", got)
+	}
+}
+
+func TestScanHostSkipDiscovery(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	// ARP never answers for loopback, so the host only looks up with -Pn
+	config := NetworkScanConfig{Network: "127.0.0.1", PortRange: strconv.Itoa(port), Timeout: time.Second, Threads: 1,
+		Discovery: []string{DiscoveryARP}}
+	report, err := ScanNetworkReportContext(context.Background(), config)
+	if err != nil || len(report.Hosts) != 0 {
+		t.Fatalf("scan without -Pn = %+v, %v; want no live host", report, err)
+	}
+
+	config.SkipDiscovery = true
+	report, err = ScanNetworkReportContext(context.Background(), config)
+	if err != nil || len(report.Hosts) != 1 {
+		t.Fatalf("scan with -Pn = %+v, %v; want one host", report, err)
+	}
+	host := report.Hosts[0]
+	if host.Discovery == nil || host.Discovery.Reason != "user-set" || len(host.OpenPorts) != 1 {
+		t.Errorf("host = %+v, want reason user-set and port %d open", host, port)
+	}
+	if got := discoverySummary(host.Discovery); got != "user-set (host discovery skipped)" {
+		t.Errorf("discoverySummary = %q", got)
+	}
+}
+
+func TestScanNetworkPingOnly(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	config := NetworkScanConfig{Network: "127.0.0.1", PortRange: "not a port spec", Timeout: time.Second, PingOnly: true,
+		Discovery: []string{DiscoveryTCPSYN}, DiscoveryTCPPorts: []int{port}}
+	report, err := ScanNetworkReportContext(context.Background(), config)
+	if err != nil || !report.PingOnly || len(report.Hosts) != 1 {
+		t.Fatalf("ping sweep = %+v, %v; want one live host", report, err)
+	}
+	host := report.Hosts[0]
+	if host.Discovery == nil || host.Discovery.Method != DiscoveryTCPSYN || host.TotalPorts != 0 || host.ScannedPorts != 0 {
+		t.Errorf("host = %+v, want a tcp-syn answer and no port scanned", host)
+	}
+	if host.MAC != "" {
+		t.Errorf("loopback MAC = %q, want none", host.MAC)
+	}
+
+	config.SkipDiscovery = true
+	if _, err := ScanNetworkReportContext(context.Background(), config); err == nil {
+		t.Error("ScanNetworkReportContext accepted a ping sweep skipping discovery")
+	}
+}
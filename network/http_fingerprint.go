@@ -0,0 +1,289 @@
+package network
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"html"
+	"io"
+	"math/bits"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPInfo is the fingerprint of a web server, taken from its answer to
+// GET / and its favicon
+type HTTPInfo struct {
+	StatusCode   int      `json:"status_code"`
+	Status       string   `json:"status"` // e.g. "200 OK"
+	Server       string   `json:"server,omitempty"`
+	Title        string   `json:"title,omitempty"`
+	Redirect     string   `json:"redirect,omitempty"`     // Location of a 3xx answer, not followed
+	FaviconHash  int32    `json:"favicon_hash,omitempty"` // Shodan-compatible MurmurHash3 of the favicon
+	Technologies []string `json:"technologies,omitempty"` // From headers, cookies and the generator meta tag
+}
+
+// maxHTTPBody caps the bytes read from a page or favicon
+const maxHTTPBody = 256 * 1024
+
+var (
+	titlePattern     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	iconLinkPattern  = regexp.MustCompile(`(?is)<link\s[^>]*rel=["']?[^"'>]*icon[^>]*>`)
+	hrefPattern      = regexp.MustCompile(`(?is)href=["']?([^"'\s>]+)`)
+	generatorPattern = regexp.MustCompile(`(?is)<meta\s[^>]*name=["']?generator["']?[^>]*content=["']([^"']+)`)
+)
+
+// technologyHeaders are the response headers naming the software stack
+var technologyHeaders = []string{
+	"X-Powered-By",
+	"X-AspNet-Version",
+	"X-AspNetMvc-Version",
+	"X-Generator",
+	"X-Drupal-Cache",
+	"X-Varnish",
+	"Via",
+}
+
+// technologyCookies maps session cookie names to the framework setting them
+var technologyCookies = map[string]string{
+	"PHPSESSID":             "PHP",
+	"JSESSIONID":            "Java",
+	"ASP.NET_SessionId":     "ASP.NET",
+	"laravel_session":       "Laravel",
+	"ci_session":            "CodeIgniter",
+	"connect.sid":           "Express",
+	"csrftoken":             "Django",
+	"_rails_session":        "Ruby on Rails",
+	"wordpress_test_cookie": "WordPress",
+}
+
+// isHTTPService reports whether a service name designates a web server
+// (http, https, http-proxy, http-alt, ...)
+func isHTTPService(service string) bool {
+	return strings.HasPrefix(strings.ToLower(service), "http")
+}
+
+// FingerprintHTTP requests / from the web server at ip:port and describes
+// it. Redirects are recorded, not followed. useTLS selects HTTPS.
+func FingerprintHTTP(ctx context.Context, ip string, port int, timeout time.Duration, useTLS bool) (*HTTPInfo, error) {
+	address := net.JoinHostPort(ip, strconv.Itoa(port))
+	dial := func() (net.Conn, error) {
+		dialer := net.Dialer{Timeout: timeout}
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil || !useTLS {
+			return conn, err
+		}
+		tlsConn, _, err := tlsHandshake(ctx, conn, timeout)
+		if err != nil {
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+	return fingerprintHTTP(ctx, address, dial, timeout)
+}
+
+// fingerprintHTTP fingerprints the web server reached with dial, which
+// opens plain or TLS connections. address is used as the Host header.
+func fingerprintHTTP(ctx context.Context, address string, dial func() (net.Conn, error), timeout time.Duration) (*HTTPInfo, error) {
+	client := &http.Client{
+		Timeout: 2 * timeout,
+		Transport: &http.Transport{
+			DialContext:       func(context.Context, string, string) (net.Conn, error) { return dial() },
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	base := &url.URL{Scheme: "http", Host: address, Path: "/"}
+
+	response, body, err := httpGet(ctx, client, base.String())
+	if err != nil {
+		return nil, err
+	}
+	info := &HTTPInfo{
+		StatusCode:   response.StatusCode,
+		Status:       response.Status,
+		Server:       response.Header.Get("Server"),
+		Title:        pageTitle(body),
+		Technologies: technologies(response, body),
+	}
+	if response.StatusCode >= 300 && response.StatusCode < 400 {
+		info.Redirect = response.Header.Get("Location")
+	}
+
+	icon := faviconURL(base, body)
+	if iconResponse, iconBody, err := httpGet(ctx, client, icon.String()); err == nil &&
+		iconResponse.StatusCode == http.StatusOK && len(iconBody) > 0 &&
+		!strings.HasPrefix(iconResponse.Header.Get("Content-Type"), "text/html") {
+		info.FaviconHash = FaviconHash(iconBody)
+	}
+	return info, nil
+}
+
+// httpGet fetches target and reads at most maxHTTPBody bytes of its body
+func httpGet(ctx context.Context, client *http.Client, target string) (*http.Response, []byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	request.Header.Set("User-Agent", "Mozilla/5.0 (compatible; network-toolkit)")
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxHTTPBody))
+	if err != nil && len(body) == 0 {
+		return nil, nil, err
+	}
+	return response, body, nil
+}
+
+// pageTitle returns the <title> of an HTML page on a single line
+func pageTitle(body []byte) string {
+	match := titlePattern.FindSubmatch(body)
+	if match == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(string(match[1]))), " ")
+}
+
+// faviconURL returns the icon declared by the page when it is on the same
+// server, else /favicon.ico
+func faviconURL(base *url.URL, body []byte) *url.URL {
+	icon := &url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/favicon.ico"}
+	link := iconLinkPattern.Find(body)
+	if link == nil {
+		return icon
+	}
+	href := hrefPattern.FindSubmatch(link)
+	if href == nil {
+		return icon
+	}
+	declared, err := base.Parse(html.UnescapeString(string(href[1])))
+	if err != nil || declared.Host != base.Host || declared.Scheme != base.Scheme {
+		return icon
+	}
+	return declared
+}
+
+// technologies lists the software revealed by the headers, the session
+// cookies and the generator meta tag, without duplicates
+func technologies(response *http.Response, body []byte) []string {
+	seen := make(map[string]bool)
+	var found []string
+	add := func(name string) {
+		name = strings.TrimSpace(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			found = append(found, name)
+		}
+	}
+
+	for _, header := range technologyHeaders {
+		for _, value := range response.Header.Values(header) {
+			switch header {
+			case "X-AspNet-Version", "X-AspNetMvc-Version":
+				add("ASP.NET " + value)
+			case "X-Drupal-Cache":
+				add("Drupal")
+			case "X-Varnish":
+				add("Varnish")
+			default:
+				add(value)
+			}
+		}
+	}
+	for _, cookie := range response.Cookies() {
+		if name, ok := technologyCookies[cookie.Name]; ok {
+			add(name)
+		}
+	}
+	if match := generatorPattern.FindSubmatch(body); match != nil {
+		add(html.UnescapeString(string(match[1])))
+	}
+	sort.Strings(found)
+	return found
+}
+
+// FaviconHash returns the hash Shodan indexes favicons by (http.favicon.hash):
+// the 32-bit MurmurHash3 of the base64 encoding of the icon, wrapped at 76
+// characters with a trailing newline
+func FaviconHash(icon []byte) int32 {
+	encoded := base64.StdEncoding.EncodeToString(icon)
+	var wrapped strings.Builder
+	for len(encoded) > 76 {
+		wrapped.WriteString(encoded[:76] + "\n")
+		encoded = encoded[76:]
+	}
+	wrapped.WriteString(encoded + "\n")
+	return int32(murmur3([]byte(wrapped.String()), 0))
+}
+
+// murmur3 is the 32-bit MurmurHash3 (x86_32) of data
+func murmur3(data []byte, seed uint32) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	hash := seed
+	length := len(data)
+
+	for ; len(data) >= 4; data = data[4:] {
+		k := binary.LittleEndian.Uint32(data)
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		hash ^= k
+		hash = bits.RotateLeft32(hash, 13)
+		hash = hash*5 + 0xe6546b64
+	}
+
+	var k uint32
+	switch len(data) {
+	case 3:
+		k ^= uint32(data[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(data[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(data[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		hash ^= k
+	}
+
+	hash ^= uint32(length)
+	hash ^= hash >> 16
+	hash *= 0x85ebca6b
+	hash ^= hash >> 13
+	hash *= 0xc2b2ae35
+	hash ^= hash >> 16
+	return hash
+}
+
+// httpSummary is the one-line description of a web server shown by the
+// text reports
+func httpSummary(h *HTTPInfo) string {
+	parts := []string{h.Status}
+	if h.Title != "" {
+		parts = append(parts, "title \""+h.Title+"\"")
+	}
+	if h.Redirect != "" {
+		parts = append(parts, "→ "+h.Redirect)
+	}
+	if h.Server != "" {
+		parts = append(parts, "server "+h.Server)
+	}
+	if len(h.Technologies) > 0 {
+		parts = append(parts, "tech "+strings.Join(h.Technologies, ", "))
+	}
+	if h.FaviconHash != 0 {
+		parts = append(parts, "favicon "+strconv.Itoa(int(h.FaviconHash)))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,164 @@
+package network
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMurmur3(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint32
+	}{
+		{"", 0},
+		{"hello", 0x248bfa47},
+		{"The quick brown fox jumps over the lazy dog", 0x2e4ff723},
+	}
+	for _, tt := range tests {
+		if got := murmur3([]byte(tt.in), 0); got != tt.want {
+			t.Errorf("murmur3(%q) = %#x, want %#x", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFaviconHash(t *testing.T) {
+	// 256 bytes encode to several wrapped base64 lines; the expected value
+	// is mmh3.hash(base64.encodebytes(icon)) as computed for Shodan
+	icon := make([]byte, 256)
+	for i := range icon {
+		icon[i] = byte(i)
+	}
+	if got := FaviconHash(icon); got != -757223386 {
+		t.Errorf("FaviconHash = %d, want -757223386", got)
+	}
+}
+
+// splitHostPort returns the IP and port of a test server URL
+func splitHostPort(t *testing.T, rawURL string) (string, int) {
+	t.Helper()
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, portText, _ := net.SplitHostPort(parsed.Host)
+	port, _ := strconv.Atoi(portText)
+	return host, port
+}
+
+func TestFingerprintHTTP(t *testing.T) {
+	icon := []byte("\x89PNG fake icon")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "nginx/1.24.0")
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("X-Powered-By", "PHP/8.2.7")
+			w.Header().Add("Set-Cookie", "PHPSESSID=abc; path=/")
+			w.Write([]byte(`<html><head><title>
+				Router &amp; Admin </title>
+				<meta name="generator" content="WordPress 6.4">
+				<link rel="shortcut icon" href="/static/icon.png"></head></html>`))
+		case "/static/icon.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(icon)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ip, port := splitHostPort(t, server.URL)
+	info, err := FingerprintHTTP(context.Background(), ip, port, time.Second, false)
+	if err != nil {
+		t.Fatalf("FingerprintHTTP: %v", err)
+	}
+	want := &HTTPInfo{
+		StatusCode:   200,
+		Status:       "200 OK",
+		Server:       "nginx/1.24.0",
+		Title:        "Router & Admin",
+		FaviconHash:  FaviconHash(icon),
+		Technologies: []string{"PHP", "PHP/8.2.7", "WordPress 6.4"},
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("FingerprintHTTP = %+v, want %+v", info, want)
+	}
+}
+
+func TestFingerprintHTTPRedirect(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-AspNet-Version", "4.0.30319")
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		// Custom 404 page served for the favicon
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<title>Login</title>"))
+	}))
+	defer server.Close()
+
+	ip, port := splitHostPort(t, server.URL)
+	info, err := FingerprintHTTP(context.Background(), ip, port, time.Second, true)
+	if err != nil {
+		t.Fatalf("FingerprintHTTP: %v", err)
+	}
+	if info.StatusCode != http.StatusFound || info.Redirect != "/login" {
+		t.Errorf("got %d redirect %q, want 302 to /login", info.StatusCode, info.Redirect)
+	}
+	if info.FaviconHash != 0 {
+		t.Errorf("favicon hash %d computed from an HTML page", info.FaviconHash)
+	}
+	if !reflect.DeepEqual(info.Technologies, []string{"ASP.NET 4.0.30319"}) {
+		t.Errorf("technologies = %v", info.Technologies)
+	}
+	if summary := httpSummary(info); summary != "302 Found, → /login, tech ASP.NET 4.0.30319" {
+		t.Errorf("httpSummary = %q", summary)
+	}
+}
+
+func TestFaviconURL(t *testing.T) {
+	base := &url.URL{Scheme: "http", Host: "10.0.0.1:8080", Path: "/"}
+	tests := []struct {
+		body string
+		want string
+	}{
+		{``, "http://10.0.0.1:8080/favicon.ico"},
+		{`<link rel="icon" href="img/fav.ico?v=2">`, "http://10.0.0.1:8080/img/fav.ico?v=2"},
+		{`<LINK href='/a.png' REL='apple-touch-icon'>`, "http://10.0.0.1:8080/a.png"},
+		{`<link rel="icon" href="https://cdn.example.com/fav.ico">`, "http://10.0.0.1:8080/favicon.ico"},
+		{`<link rel="stylesheet" href="/style.css">`, "http://10.0.0.1:8080/favicon.ico"},
+	}
+	for _, tt := range tests {
+		if got := faviconURL(base, []byte(tt.body)).String(); got != tt.want {
+			t.Errorf("faviconURL(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestServiceProberHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "nginx/1.24.0")
+		w.Write([]byte("<title>Welcome to nginx!</title>"))
+	}))
+	defer server.Close()
+
+	ip, port := splitHostPort(t, server.URL)
+	result := ServiceProber{Timeout: 300 * time.Millisecond}.Probe(context.Background(), ip, port)
+	if result.Service != "http" || result.HTTP == nil {
+		t.Fatalf("got service %q HTTP %+v, want an HTTP fingerprint", result.Service, result.HTTP)
+	}
+	if result.HTTP.Title != "Welcome to nginx!" || result.HTTP.Server != "nginx/1.24.0" {
+		t.Errorf("HTTP = %+v", result.HTTP)
+	}
+	if details := portDetails(result); len(details) != 1 || !strings.HasPrefix(details[0], "🌐 HTTP: 200 OK, title \"Welcome to nginx!\"") {
+		t.Errorf("portDetails = %q", details)
+	}
+}
@@ -0,0 +1,84 @@
+package network
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// ErrICMPUnsupported is returned by the ICMP probes on systems where they
+// are not implemented
+var ErrICMPUnsupported = errors.New("ICMP probes are not supported on this system")
+
+// ICMP message types (RFC 792, RFC 4443)
+const (
+	icmpEchoReply        = 0
+	icmpEchoRequest      = 8
+	icmpTimestampRequest = 13
+	icmpTimestampReply   = 14
+	icmpv6EchoRequest    = 128
+	icmpv6EchoReply      = 129
+)
+
+// icmpReply is the answer to an ICMP probe
+type icmpReply struct {
+	TTL int           // TTL (hop limit) of the reply, 0 when unknown
+	RTT time.Duration // Round-trip time
+}
+
+// icmpEchoMessage builds an echo request. IPv4 messages carry their
+// checksum; the kernel computes the ICMPv6 one.
+func icmpEchoMessage(ipv6 bool, id, seq uint16, payload []byte) []byte {
+	message := make([]byte, 8, 8+len(payload))
+	message[0] = icmpEchoRequest
+	if ipv6 {
+		message[0] = icmpv6EchoRequest
+	}
+	binary.BigEndian.PutUint16(message[4:], id)
+	binary.BigEndian.PutUint16(message[6:], seq)
+	message = append(message, payload...)
+	if !ipv6 {
+		binary.BigEndian.PutUint16(message[2:], icmpChecksum(message))
+	}
+	return message
+}
+
+// icmpTimestampMessage builds an IPv4 timestamp request originated at now
+// (RFC 792: milliseconds since midnight UTC)
+func icmpTimestampMessage(id, seq uint16, now time.Time) []byte {
+	message := make([]byte, 20)
+	message[0] = icmpTimestampRequest
+	binary.BigEndian.PutUint16(message[4:], id)
+	binary.BigEndian.PutUint16(message[6:], seq)
+	now = now.UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	binary.BigEndian.PutUint32(message[8:], uint32(now.Sub(midnight).Milliseconds()))
+	binary.BigEndian.PutUint16(message[2:], icmpChecksum(message))
+	return message
+}
+
+// icmpChecksum is the Internet checksum (RFC 1071) of data
+func icmpChecksum(data []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(data[i:]))
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	for sum > 0xffff {
+		sum = sum>>16 + sum&0xffff
+	}
+	return ^uint16(sum)
+}
+
+// initialTTL returns the initial TTL a host most likely used to send a
+// packet received with ttl: the smallest common default not below it
+func initialTTL(ttl int) int {
+	for _, initial := range []int{32, 64, 128, 255} {
+		if ttl <= initial {
+			return initial
+		}
+	}
+	return 255
+}
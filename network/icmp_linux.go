@@ -0,0 +1,203 @@
+//go:build linux
+
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// icmpPollInterval bounds each blocking receive, so cancellation is noticed
+const icmpPollInterval = 100 * time.Millisecond
+
+// icmpSocket is an ICMP or ICMPv6 socket. Datagram sockets are the
+// unprivileged "ping" sockets (net.ipv4.ping_group_range): the kernel
+// picks the echo identifier and only delivers the replies to it. Raw
+// sockets need CAP_NET_RAW and receive every ICMP message.
+type icmpSocket struct {
+	fd   int
+	ipv6 bool
+	raw  bool
+}
+
+// openICMPSocket opens an unprivileged ICMP socket, or a raw one when the
+// system does not allow them or raw is set (ping sockets only send echo
+// requests)
+func openICMPSocket(ipv6, raw bool) (*icmpSocket, error) {
+	family, protocol := syscall.AF_INET, syscall.IPPROTO_ICMP
+	if ipv6 {
+		family, protocol = syscall.AF_INET6, syscall.IPPROTO_ICMPV6
+	}
+
+	socket := &icmpSocket{ipv6: ipv6}
+	fd, err := -1, error(nil)
+	if !raw {
+		fd, err = syscall.Socket(family, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, protocol)
+	}
+	if raw || err != nil {
+		socket.raw = true
+		if fd, err = syscall.Socket(family, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, protocol); err != nil {
+			return nil, fmt.Errorf("opening ICMP socket (needs net.ipv4.ping_group_range or CAP_NET_RAW): %v", err)
+		}
+	}
+	socket.fd = fd
+
+	// Report the TTL (hop limit) of received packets
+	if ipv6 {
+		err = syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_RECVHOPLIMIT, 1)
+	} else {
+		err = syscall.SetsockoptInt(fd, syscall.IPPROTO_IP, syscall.IP_RECVTTL, 1)
+	}
+	if err == nil {
+		tv := syscall.NsecToTimeval(icmpPollInterval.Nanoseconds())
+		err = syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv)
+	}
+	if err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("configuring ICMP socket: %v", err)
+	}
+	return socket, nil
+}
+
+// close releases the socket
+func (s *icmpSocket) close() { syscall.Close(s.fd) }
+
+// sockaddr converts addr to the socket address of the socket's family
+func (s *icmpSocket) sockaddr(addr netip.Addr) syscall.Sockaddr {
+	if s.ipv6 {
+		return &syscall.SockaddrInet6{Addr: addr.As16()}
+	}
+	return &syscall.SockaddrInet4{Addr: addr.As4()}
+}
+
+// receive waits until deadline for an ICMP message from addr accepted by
+// match, and returns the TTL it arrived with
+func (s *icmpSocket) receive(ctx context.Context, addr netip.Addr, deadline time.Time, match func(message []byte) bool) (int, error) {
+	buffer := make([]byte, 1500)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	for time.Now().Before(deadline) {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		n, oobn, _, from, err := syscall.Recvmsg(s.fd, buffer, oob, 0)
+		if err == syscall.EAGAIN || err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !sockaddrIs(from, addr) {
+			continue
+		}
+
+		message := buffer[:n]
+		if s.raw && !s.ipv6 {
+			// Raw IPv4 sockets return the IP header too
+			headerLen := int(message[0]&0x0f) * 4
+			if len(message) < headerLen+8 {
+				continue
+			}
+			message = message[headerLen:]
+		}
+		if len(message) < 8 || !match(message) {
+			continue
+		}
+		return receivedTTL(oob[:oobn]), nil
+	}
+	return 0, errors.New("no ICMP reply")
+}
+
+// icmpEcho sends an ICMP echo request to addr and waits for the reply
+func icmpEcho(ctx context.Context, addr netip.Addr, timeout time.Duration) (icmpReply, error) {
+	addr = addr.Unmap()
+	socket, err := openICMPSocket(addr.Is6(), false)
+	if err != nil {
+		return icmpReply{}, err
+	}
+	defer socket.close()
+
+	id, seq := uint16(rand.Intn(0x10000)), uint16(rand.Intn(0x10000))
+	replyType := byte(icmpEchoReply)
+	if socket.ipv6 {
+		replyType = icmpv6EchoReply
+	}
+
+	start := time.Now()
+	request := icmpEchoMessage(socket.ipv6, id, seq, []byte("network-toolkit"))
+	if err := syscall.Sendto(socket.fd, request, 0, socket.sockaddr(addr)); err != nil {
+		return icmpReply{}, fmt.Errorf("sending ICMP echo: %v", err)
+	}
+
+	ttl, err := socket.receive(ctx, addr, start.Add(timeout), func(message []byte) bool {
+		// Ping sockets rewrite the identifier, and filter on it themselves
+		return message[0] == replyType && binary.BigEndian.Uint16(message[6:]) == seq &&
+			(!socket.raw || binary.BigEndian.Uint16(message[4:]) == id)
+	})
+	if err != nil {
+		return icmpReply{}, err
+	}
+	return icmpReply{TTL: ttl, RTT: time.Since(start)}, nil
+}
+
+// icmpTimestamp sends an ICMP timestamp request to an IPv4 addr and waits
+// for the reply. Ping sockets only carry echoes, so it needs CAP_NET_RAW.
+func icmpTimestamp(ctx context.Context, addr netip.Addr, timeout time.Duration) (icmpReply, error) {
+	addr = addr.Unmap()
+	if !addr.Is4() {
+		return icmpReply{}, errors.New("ICMP timestamps are IPv4 only")
+	}
+	socket, err := openICMPSocket(false, true)
+	if err != nil {
+		return icmpReply{}, err
+	}
+	defer socket.close()
+
+	id, seq := uint16(rand.Intn(0x10000)), uint16(rand.Intn(0x10000))
+	start := time.Now()
+	request := icmpTimestampMessage(id, seq, start)
+	if err := syscall.Sendto(socket.fd, request, 0, socket.sockaddr(addr)); err != nil {
+		return icmpReply{}, fmt.Errorf("sending ICMP timestamp: %v", err)
+	}
+
+	ttl, err := socket.receive(ctx, addr, start.Add(timeout), func(message []byte) bool {
+		return message[0] == icmpTimestampReply && binary.BigEndian.Uint16(message[4:]) == id &&
+			binary.BigEndian.Uint16(message[6:]) == seq
+	})
+	if err != nil {
+		return icmpReply{}, err
+	}
+	return icmpReply{TTL: ttl, RTT: time.Since(start)}, nil
+}
+
+// receivedTTL extracts the IP_TTL or IPV6_HOPLIMIT control message
+func receivedTTL(oob []byte) int {
+	messages, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return 0
+	}
+	for _, message := range messages {
+		ttlMessage := message.Header.Level == syscall.IPPROTO_IP && message.Header.Type == syscall.IP_TTL
+		hopLimitMessage := message.Header.Level == syscall.IPPROTO_IPV6 && message.Header.Type == syscall.IPV6_HOPLIMIT
+		if (ttlMessage || hopLimitMessage) && len(message.Data) >= 4 {
+			return int(binary.NativeEndian.Uint32(message.Data))
+		}
+	}
+	return 0
+}
+
+// sockaddrIs reports whether a socket address is addr
+func sockaddrIs(sa syscall.Sockaddr, addr netip.Addr) bool {
+	switch sa := sa.(type) {
+	case *syscall.SockaddrInet4:
+		return netip.AddrFrom4(sa.Addr) == addr
+	case *syscall.SockaddrInet6:
+		return netip.AddrFrom16(sa.Addr).Unmap() == addr
+	}
+	return false
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package network
+
+import (
+	"context"
+	"net/netip"
+	"time"
+)
+
+// icmpEcho is only implemented on Linux
+func icmpEcho(ctx context.Context, addr netip.Addr, timeout time.Duration) (icmpReply, error) {
+	return icmpReply{}, ErrICMPUnsupported
+}
+
+// icmpTimestamp is only implemented on Linux
+func icmpTimestamp(ctx context.Context, addr netip.Addr, timeout time.Duration) (icmpReply, error) {
+	return icmpReply{}, ErrICMPUnsupported
+}
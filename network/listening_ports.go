@@ -1,125 +1,159 @@
-package network
-
-import (
-	"fmt"
-
-	"github.com/shirou/gopsutil/v3/net"
-	"github.com/shirou/gopsutil/v3/process"
-)
-
-// PortInfo represents information about a listening port
-type PortInfo struct {
-	LocalAddr   string
-	LocalPort   uint32
-	State       string
-	PID         int32
-	ProcessName string
-}
-
-// ListListeningPorts lists all TCP ports in listening state
-func ListListeningPorts() ([]PortInfo, error) {
-	var ports []PortInfo
-
-	// Get all TCP connections using gopsutil
-	connections, err := net.Connections("tcp")
-	if err != nil {
-		return nil, fmt.Errorf("error getting TCP connections: %v", err)
-	}
-
-	// Filter only LISTEN state connections
-	for _, conn := range connections {
-		if conn.Status == "LISTEN" {
-			processName := "Unknown"
-
-			// Try to get process name
-			if conn.Pid > 0 {
-				proc, err := process.NewProcess(conn.Pid)
-				if err == nil {
-					if name, err := proc.Name(); err == nil {
-						processName = name
-					}
-				}
-			}
-
-			ports = append(ports, PortInfo{
-				LocalAddr:   conn.Laddr.IP,
-				LocalPort:   conn.Laddr.Port,
-				State:       conn.Status,
-				PID:         conn.Pid,
-				ProcessName: processName,
-			})
-		}
-	}
-
-	return ports, nil
-}
-
-// PrintListeningPorts prints listening ports in a formatted way
-func PrintListeningPorts() error {
-	ports, err := ListListeningPorts()
-	if err != nil {
-		return err
-	}
-
-	if len(ports) == 0 {
-		fmt.Println("\nNo listening ports found.")
-		return nil
-	}
-
-	fmt.Println("\n=== LISTENING PORTS ===")
-	fmt.Printf("%-20s %-10s %-15s %-10s %-s\n", "ADDRESS", "PORT", "STATE", "PID", "PROCESS")
-	fmt.Println("--------------------------------------------------------------------------------------------")
-
-	for _, port := range ports {
-		fmt.Printf("%-20s %-10d %-15s %-10d %-s\n",
-			port.LocalAddr,
-			port.LocalPort,
-			port.State,
-			port.PID,
-			port.ProcessName,
-		)
-	}
-
-	fmt.Printf("\nTotal: %d listening port(s)\n", len(ports))
-	return nil
-}
-
-func GetListeningPortsCount() (int, error) {
-	ports, err := ListListeningPorts()
-	if err != nil {
-		return 0, err
-	}
-	return len(ports), nil
-}
-
-// IsPortListening verifica se uma porta específica está em escuta
-func IsPortListening(port uint32) (bool, error) {
-	ports, err := ListListeningPorts()
-	if err != nil {
-		return false, err
-	}
-
-	for _, p := range ports {
-		if p.LocalPort == port {
-			return true, nil
-		}
-	}
-
-	return false, nil
-}
-
-// GetProcessByPort retorna o nome do processo usando uma porta específica
-func GetProcessByPort(port uint32) (string, error) {
-	ports, err := ListListeningPorts()
-	if err != nil {
-		return "", err
-	}
-
-	for _, p := range ports {
-		if p.LocalPort == port {
-			return p.ProcessName, nil
-		}
-	}
-
-	return "", fmt.Errorf("porta %d não está em escuta", port)
-}
+package network
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// PortInfo represents information about a listening port
+type PortInfo struct {
+	LocalAddr   string `json:"local_addr"`
+	LocalPort   uint32 `json:"local_port"`
+	Protocol    string `json:"protocol"` // tcp, tcp6, udp, udp6
+	State       string `json:"state"`
+	Service     string `json:"service"` // Registered service of the port (see LookupService)
+	PID         int32  `json:"pid"`
+	ProcessName string `json:"process_name"`
+}
+
+// ListListeningPorts lists all TCP ports in listening state and all bound
+// UDP sockets, over both IPv4 and IPv6
+func ListListeningPorts() ([]PortInfo, error) {
+	var ports []PortInfo
+
+	// Get all TCP and UDP sockets (IPv4 and IPv6) using gopsutil
+	connections, err := net.Connections("inet")
+	if err != nil {
+		return nil, fmt.Errorf("error getting connections: %v", err)
+	}
+
+	for _, conn := range connections {
+		port, ok := listeningPortInfo(conn)
+		if !ok {
+			continue
+		}
+
+		// Try to get process name
+		if conn.Pid > 0 {
+			proc, err := process.NewProcess(conn.Pid)
+			if err == nil {
+				if name, err := proc.Name(); err == nil {
+					port.ProcessName = name
+				}
+			}
+		}
+
+		ports = append(ports, port)
+	}
+
+	return ports, nil
+}
+
+// listeningPortInfo converts a socket to a PortInfo when it accepts traffic:
+// TCP sockets in LISTEN state and UDP sockets without a remote peer, which
+// are reported as LISTEN too
+func listeningPortInfo(conn net.ConnectionStat) (PortInfo, bool) {
+	protocol := "tcp"
+	switch {
+	case conn.Type == syscall.SOCK_STREAM && conn.Status == "LISTEN":
+	case conn.Type == syscall.SOCK_DGRAM && conn.Raddr.Port == 0:
+		protocol = "udp"
+	default:
+		return PortInfo{}, false
+	}
+	if conn.Family == syscall.AF_INET6 {
+		protocol += "6"
+	}
+
+	return PortInfo{
+		LocalAddr:   conn.Laddr.IP,
+		LocalPort:   conn.Laddr.Port,
+		Protocol:    protocol,
+		State:       "LISTEN",
+		Service:     ServiceName(protocol, int(conn.Laddr.Port)),
+		PID:         conn.Pid,
+		ProcessName: "Unknown",
+	}, true
+}
+
+// PrintListeningPorts prints listening ports in a formatted way
+func PrintListeningPorts() error {
+	ports, err := ListListeningPorts()
+	if err != nil {
+		return err
+	}
+
+	FprintListeningPorts(os.Stdout, ports)
+	return nil
+}
+
+// FprintListeningPorts writes listening ports in a formatted way to w
+func FprintListeningPorts(w io.Writer, ports []PortInfo) {
+	if len(ports) == 0 {
+		fmt.Fprintln(w, "\nNo listening ports found.")
+		return
+	}
+
+	fmt.Fprintln(w, "\n=== LISTENING PORTS ===")
+	fmt.Fprintf(w, "%-28s %-10s %-6s %-10s %-16s %-10s %-s\n", "ADDRESS", "PORT", "PROTO", "STATE", "SERVICE", "PID", "PROCESS")
+	fmt.Fprintln(w, "-------------------------------------------------------------------------------------------------------------")
+
+	for _, port := range ports {
+		fmt.Fprintf(w, "%-28s %-10d %-6s %-10s %-16s %-10d %-s\n",
+			port.LocalAddr,
+			port.LocalPort,
+			port.Protocol,
+			port.State,
+			port.Service,
+			port.PID,
+			port.ProcessName,
+		)
+	}
+
+	fmt.Fprintf(w, "\nTotal: %d listening port(s)\n", len(ports))
+}
+
+func GetListeningPortsCount() (int, error) {
+	ports, err := ListListeningPorts()
+	if err != nil {
+		return 0, err
+	}
+	return len(ports), nil
+}
+
+// IsPortListening verifica se uma porta específica está em escuta
+func IsPortListening(port uint32) (bool, error) {
+	ports, err := ListListeningPorts()
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range ports {
+		if p.LocalPort == port {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// GetProcessByPort retorna o nome do processo usando uma porta específica
+func GetProcessByPort(port uint32) (string, error) {
+	ports, err := ListListeningPorts()
+	if err != nil {
+		return "", err
+	}
+
+	for _, p := range ports {
+		if p.LocalPort == port {
+			return p.ProcessName, nil
+		}
+	}
+
+	return "", fmt.Errorf("porta %d não está em escuta", port)
+}
@@ -0,0 +1,43 @@
+package network
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+func TestListeningPortInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		conn     net.ConnectionStat
+		protocol string
+		service  string
+		ok       bool
+	}{
+		{"tcp listen", net.ConnectionStat{Family: syscall.AF_INET, Type: syscall.SOCK_STREAM, Status: "LISTEN",
+			Laddr: net.Addr{IP: "0.0.0.0", Port: 22}}, "tcp", "ssh", true},
+		{"tcp6 listen", net.ConnectionStat{Family: syscall.AF_INET6, Type: syscall.SOCK_STREAM, Status: "LISTEN",
+			Laddr: net.Addr{IP: "::", Port: 22}}, "tcp6", "ssh", true},
+		{"tcp established", net.ConnectionStat{Family: syscall.AF_INET, Type: syscall.SOCK_STREAM, Status: "ESTABLISHED",
+			Laddr: net.Addr{IP: "10.0.0.2", Port: 51000}, Raddr: net.Addr{IP: "10.0.0.1", Port: 443}}, "", "", false},
+		{"udp bound", net.ConnectionStat{Family: syscall.AF_INET, Type: syscall.SOCK_DGRAM, Status: "NONE",
+			Laddr: net.Addr{IP: "127.0.0.53", Port: 53}}, "udp", "domain", true},
+		{"udp6 bound", net.ConnectionStat{Family: syscall.AF_INET6, Type: syscall.SOCK_DGRAM, Status: "NONE",
+			Laddr: net.Addr{IP: "::", Port: 5353}, Raddr: net.Addr{IP: "::"}}, "udp6", "mdns", true},
+		{"udp connected", net.ConnectionStat{Family: syscall.AF_INET, Type: syscall.SOCK_DGRAM, Status: "NONE",
+			Laddr: net.Addr{IP: "10.0.0.2", Port: 40000}, Raddr: net.Addr{IP: "10.0.0.1", Port: 53}}, "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port, ok := listeningPortInfo(tt.conn)
+			if ok != tt.ok || port.Protocol != tt.protocol {
+				t.Fatalf("listeningPortInfo = %+v, %v; want protocol %q, %v", port, ok, tt.protocol, tt.ok)
+			}
+			if ok && (port.LocalAddr != tt.conn.Laddr.IP || port.LocalPort != tt.conn.Laddr.Port || port.State != "LISTEN" || port.Service != tt.service) {
+				t.Errorf("listeningPortInfo = %+v", port)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package network
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// macVendorData is the embedded MAC address vendor database
+//
+//go:embed data/mac-vendors.txt
+var macVendorData string
+
+// macVendors maps OUIs ("000C29") to vendor names, loaded on first use
+var macVendors = sync.OnceValue(func() map[string]string {
+	return parseMACVendors(macVendorData)
+})
+
+// parseMACVendors parses lines of the form "<OUI> <vendor>", skipping
+// comments and malformed lines
+func parseMACVendors(data string) map[string]string {
+	vendors := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		oui, vendor, found := strings.Cut(strings.TrimSpace(scanner.Text()), "\t")
+		if !found || len(oui) != 6 || strings.HasPrefix(oui, "#") {
+			continue
+		}
+		if vendor = strings.TrimSpace(vendor); vendor != "" {
+			vendors[strings.ToUpper(oui)] = vendor
+		}
+	}
+	return vendors
+}
+
+// MACVendor returns the vendor of a MAC address from the embedded OUI
+// database, or "" when it is unknown. Locally administered addresses are
+// random or virtual and have no vendor, except the prefixes some
+// hypervisors use (52:54:00 for QEMU).
+func MACVendor(mac string) string {
+	hardwareAddr, err := net.ParseMAC(mac)
+	if err != nil || len(hardwareAddr) < 3 {
+		return ""
+	}
+	return macVendors()[fmt.Sprintf("%02X%02X%02X", hardwareAddr[0], hardwareAddr[1], hardwareAddr[2])]
+}
@@ -0,0 +1,28 @@
+package network
+
+import "testing"
+
+func TestParseMACVendors(t *testing.T) {
+	vendors := parseMACVendors("# comment\n000c29\tVMware\nB827EB\tRaspberry Pi Foundation\n12345\tShort\n525400\t\n")
+	if len(vendors) != 2 || vendors["000C29"] != "VMware" || vendors["B827EB"] != "Raspberry Pi Foundation" {
+		t.Errorf("parseMACVendors = %v, want 000C29 and B827EB", vendors)
+	}
+}
+
+func TestMACVendor(t *testing.T) {
+	tests := []struct {
+		mac, want string
+	}{
+		{"00:0c:29:4f:8e:35", "VMware"},
+		{"B8-27-EB-12-34-56", "Raspberry Pi Foundation"},
+		{"52:54:00:12:34:56", "QEMU virtual NIC"},
+		{"02:fc:00:00:00:05", ""}, // Locally administered
+		{"00:00:5e:00:53:01", ""}, // Not in the database
+		{"not a mac", ""},
+	}
+	for _, tt := range tests {
+		if got := MACVendor(tt.mac); got != tt.want {
+			t.Errorf("MACVendor(%q) = %q, want %q", tt.mac, got, tt.want)
+		}
+	}
+}
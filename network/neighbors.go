@@ -0,0 +1,52 @@
+package network
+
+import (
+	"errors"
+	"net/netip"
+)
+
+// ErrNeighborsUnsupported is returned by Neighbors on systems whose
+// neighbour table cannot be read
+var ErrNeighborsUnsupported = errors.New("reading the neighbour table is not supported on this system")
+
+// Neighbor is an entry of the system neighbour table (ARP for IPv4, NDP
+// for IPv6): a host recently seen on a directly attached link
+type Neighbor struct {
+	IP        string `json:"ip"` // Link-local IPv6 addresses carry their zone (fe80::1%eth0)
+	MAC       string `json:"mac,omitempty"`
+	Interface string `json:"interface,omitempty"`
+}
+
+// Neighbors returns the reachable entries of the system neighbour table
+func Neighbors() ([]Neighbor, error) {
+	return systemNeighbors()
+}
+
+// neighborsInPrefix returns the addresses of the neighbours inside prefix
+func neighborsInPrefix(neighbors []Neighbor, prefix netip.Prefix) []string {
+	var ips []string
+	for _, neighbor := range neighbors {
+		addr, err := netip.ParseAddr(neighbor.IP)
+		if err != nil {
+			continue
+		}
+		if prefix.Contains(addr.WithZone("").Unmap()) {
+			ips = append(ips, neighbor.IP)
+		}
+	}
+	return ips
+}
+
+// neighborMAC returns the MAC address of ip in the neighbour table, or ""
+func neighborMAC(neighbors []Neighbor, ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	for _, neighbor := range neighbors {
+		if entry, err := netip.ParseAddr(neighbor.IP); err == nil && entry.Unmap() == addr.Unmap() {
+			return neighbor.MAC
+		}
+	}
+	return ""
+}
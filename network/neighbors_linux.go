@@ -0,0 +1,96 @@
+//go:build linux
+
+package network
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// Neighbour table constants from linux/neighbour.h
+const (
+	ndaDst    = 1 // NDA_DST: neighbour address
+	ndaLLAddr = 2 // NDA_LLADDR: link-layer address
+
+	nudIncomplete = 0x01
+	nudFailed     = 0x20
+	nudNoARP      = 0x40
+
+	ndMsgLen = 12 // sizeof(struct ndmsg)
+)
+
+// systemNeighbors dumps the kernel neighbour table over rtnetlink
+func systemNeighbors() ([]Neighbor, error) {
+	data, err := syscall.NetlinkRIB(syscall.RTM_GETNEIGH, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, fmt.Errorf("error reading neighbour table: %v", err)
+	}
+	messages, err := syscall.ParseNetlinkMessage(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing neighbour table: %v", err)
+	}
+
+	interfaces := map[int32]string{}
+	if ifaces, err := net.Interfaces(); err == nil {
+		for _, iface := range ifaces {
+			interfaces[int32(iface.Index)] = iface.Name
+		}
+	}
+
+	return parseNeighborMessages(messages, interfaces), nil
+}
+
+// parseNeighborMessages converts RTM_NEWNEIGH messages to neighbours,
+// skipping unresolved, failed and static no-ARP entries
+func parseNeighborMessages(messages []syscall.NetlinkMessage, interfaces map[int32]string) []Neighbor {
+	var neighbors []Neighbor
+	for _, message := range messages {
+		if message.Header.Type != syscall.RTM_NEWNEIGH || len(message.Data) < ndMsgLen {
+			continue
+		}
+
+		// struct ndmsg: family, pad, pad, ifindex, state, flags, type
+		ifindex := int32(binary.NativeEndian.Uint32(message.Data[4:8]))
+		state := binary.NativeEndian.Uint16(message.Data[8:10])
+		if state&(nudIncomplete|nudFailed|nudNoARP) != 0 {
+			continue
+		}
+
+		neighbor := Neighbor{Interface: interfaces[ifindex]}
+		for attrs := message.Data[ndMsgLen:]; len(attrs) >= 4; {
+			length := int(binary.NativeEndian.Uint16(attrs[0:2]))
+			if length < 4 || length > len(attrs) {
+				break
+			}
+			value := attrs[4:length]
+
+			switch binary.NativeEndian.Uint16(attrs[2:4]) {
+			case ndaDst:
+				if addr, ok := netip.AddrFromSlice(value); ok {
+					addr = addr.Unmap()
+					if addr.Is6() && addr.IsLinkLocalUnicast() && neighbor.Interface != "" {
+						addr = addr.WithZone(neighbor.Interface)
+					}
+					neighbor.IP = addr.String()
+				}
+			case ndaLLAddr:
+				neighbor.MAC = net.HardwareAddr(value).String()
+			}
+
+			// Attributes are aligned to 4 bytes
+			aligned := (length + 3) &^ 3
+			if aligned > len(attrs) {
+				break
+			}
+			attrs = attrs[aligned:]
+		}
+
+		if neighbor.IP != "" {
+			neighbors = append(neighbors, neighbor)
+		}
+	}
+	return neighbors
+}
@@ -0,0 +1,63 @@
+//go:build linux
+
+package network
+
+import (
+	"encoding/binary"
+	"syscall"
+	"testing"
+)
+
+// neighborMessage builds an RTM_NEWNEIGH message like the kernel sends
+func neighborMessage(ifindex int32, state uint16, dst, lladdr []byte) syscall.NetlinkMessage {
+	data := make([]byte, ndMsgLen)
+	binary.NativeEndian.PutUint32(data[4:8], uint32(ifindex))
+	binary.NativeEndian.PutUint16(data[8:10], state)
+
+	for _, attr := range []struct {
+		kind  uint16
+		value []byte
+	}{{ndaDst, dst}, {ndaLLAddr, lladdr}} {
+		if attr.value == nil {
+			continue
+		}
+		header := make([]byte, 4)
+		binary.NativeEndian.PutUint16(header[0:2], uint16(4+len(attr.value)))
+		binary.NativeEndian.PutUint16(header[2:4], attr.kind)
+		data = append(data, header...)
+		data = append(data, attr.value...)
+		for len(data)%4 != 0 {
+			data = append(data, 0)
+		}
+	}
+
+	return syscall.NetlinkMessage{Header: syscall.NlMsghdr{Type: syscall.RTM_NEWNEIGH}, Data: data}
+}
+
+func TestParseNeighborMessages(t *testing.T) {
+	const nudReachable, nudStale = 0x02, 0x04
+	mac := []byte{0x02, 0xfc, 0x00, 0x00, 0x00, 0x05}
+	linkLocal := []byte{0xfe, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}
+
+	messages := []syscall.NetlinkMessage{
+		neighborMessage(2, nudReachable, []byte{192, 168, 1, 1}, mac),
+		neighborMessage(2, nudStale, linkLocal, mac),
+		neighborMessage(2, nudFailed, []byte{192, 168, 1, 2}, nil),
+		neighborMessage(1, nudNoARP, []byte{127, 0, 0, 1}, nil),
+		{Header: syscall.NlMsghdr{Type: syscall.NLMSG_DONE}},
+	}
+
+	got := parseNeighborMessages(messages, map[int32]string{1: "lo", 2: "eth0"})
+	want := []Neighbor{
+		{IP: "192.168.1.1", MAC: "02:fc:00:00:00:05", Interface: "eth0"},
+		{IP: "fe80::1%eth0", MAC: "02:fc:00:00:00:05", Interface: "eth0"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseNeighborMessages = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("neighbor %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
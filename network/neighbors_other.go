@@ -0,0 +1,8 @@
+//go:build !linux
+
+package network
+
+// systemNeighbors is only implemented on Linux
+func systemNeighbors() ([]Neighbor, error) {
+	return nil, ErrNeighborsUnsupported
+}
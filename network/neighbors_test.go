@@ -0,0 +1,52 @@
+package network
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestNeighborsInPrefix(t *testing.T) {
+	neighbors := []Neighbor{
+		{IP: "192.168.1.1"},
+		{IP: "2001:db8::10"},
+		{IP: "2001:db8:1::10"},
+		{IP: "fe80::1%eth0"},
+		{IP: "garbage"},
+	}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"2001:db8::/64", []string{"2001:db8::10"}},
+		{"fe80::/64", []string{"fe80::1%eth0"}},
+		{"192.168.0.0/16", []string{"192.168.1.1"}},
+		{"10.0.0.0/8", nil},
+	}
+
+	for _, tt := range tests {
+		got := neighborsInPrefix(neighbors, netip.MustParsePrefix(tt.prefix))
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("neighborsInPrefix(%s) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestNeighborMAC(t *testing.T) {
+	neighbors := []Neighbor{
+		{IP: "192.168.1.1", MAC: "02:fc:00:00:00:05"},
+		{IP: "fe80::1%eth0", MAC: "00:0c:29:4f:8e:35"},
+	}
+	for ip, want := range map[string]string{
+		"192.168.1.1":  "02:fc:00:00:00:05",
+		"fe80::1%eth0": "00:0c:29:4f:8e:35",
+		"fe80::1%eth1": "",
+		"192.168.1.2":  "",
+		"router.lan":   "",
+	} {
+		if got := neighborMAC(neighbors, ip); got != want {
+			t.Errorf("neighborMAC(%s) = %q, want %q", ip, got, want)
+		}
+	}
+}
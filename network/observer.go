@@ -0,0 +1,212 @@
+package network
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Kinds of scan reported in ScanInfo
+const (
+	ScanKindNetwork  = "network"
+	ScanKindStealthy = "stealth"
+)
+
+// ScanInfo describes a scan when it starts
+type ScanInfo struct {
+	Kind      string // ScanKindNetwork or ScanKindStealthy
+	Target    string // CIDR or target IP
+	Hostname  string // Reverse DNS of the target (single-host scans)
+	Hosts     int    // Hosts to scan (0 when unknown)
+	Ports     int    // Ports per host
+	PortRange string // Human-readable port selection
+	Protocol  string // Protocols probed ("tcp", "udp" or "tcp+udp")
+	Threads   int
+	Timeout   time.Duration
+	Timing    string // Timing template label
+	ScanType  string // TCP technique of single-host scans (connect, syn, ack, ...)
+	PingOnly  bool   // Network scan finding the live hosts only
+}
+
+// ScanProgress reports how much of a scan is done
+type ScanProgress struct {
+	Unit    string // "hosts" for network scans, "ports" for single-host scans
+	Done    int
+	Total   int // 0 when unknown
+	Percent float64
+	Elapsed time.Duration
+	ETA     time.Duration // Estimated time remaining (0 when unknown)
+}
+
+// ScanObserver receives events emitted by the scanners. Calls are
+// serialized by the scanner, so implementations need no locking, but they
+// run on the scan path and should return quickly.
+type ScanObserver interface {
+	ScanStarted(info ScanInfo)
+	HostStarted(ip string)
+	HostFinished(result HostScanResult)
+	PortScanned(result PortResult)
+	Progress(progress ScanProgress)
+}
+
+// NopObserver ignores every event. Embed it to implement only the events
+// you care about.
+type NopObserver struct{}
+
+func (NopObserver) ScanStarted(ScanInfo)        {}
+func (NopObserver) HostStarted(string)          {}
+func (NopObserver) HostFinished(HostScanResult) {}
+func (NopObserver) PortScanned(PortResult)      {}
+func (NopObserver) Progress(ScanProgress)       {}
+
+// scanEvents serializes calls to an optional observer
+type scanEvents struct {
+	mu       sync.Mutex
+	observer ScanObserver
+	start    time.Time
+}
+
+// newScanEvents wraps observer; a nil observer discards every event
+func newScanEvents(observer ScanObserver) *scanEvents {
+	return &scanEvents{observer: observer, start: time.Now()}
+}
+
+func (e *scanEvents) scanStarted(info ScanInfo) {
+	e.emit(func(o ScanObserver) { o.ScanStarted(info) })
+}
+
+func (e *scanEvents) hostStarted(ip string) {
+	e.emit(func(o ScanObserver) { o.HostStarted(ip) })
+}
+
+func (e *scanEvents) hostFinished(result HostScanResult) {
+	e.emit(func(o ScanObserver) { o.HostFinished(result) })
+}
+
+func (e *scanEvents) portScanned(result PortResult) {
+	e.emit(func(o ScanObserver) { o.PortScanned(result) })
+}
+
+// progress computes percentage, elapsed time and ETA before emitting
+func (e *scanEvents) progress(unit string, done, total int) {
+	if e.observer == nil {
+		return
+	}
+
+	progress := ScanProgress{Unit: unit, Done: done, Total: total, Elapsed: time.Since(e.start)}
+	if total > 0 {
+		progress.Percent = float64(done) / float64(total) * 100
+		if done > 0 && done < total {
+			perItem := progress.Elapsed / time.Duration(done)
+			progress.ETA = perItem * time.Duration(total-done)
+		}
+	}
+	e.emit(func(o ScanObserver) { o.Progress(progress) })
+}
+
+func (e *scanEvents) emit(call func(ScanObserver)) {
+	if e.observer == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	call(e.observer)
+}
+
+// ConsoleObserver prints scan progress in the toolkit's console style.
+// Single-host scans show every open port as soon as it is found; network
+// scans show one line per live host.
+type ConsoleObserver struct {
+	w            io.Writer
+	kind         string
+	pingOnly     bool
+	lastProgress int // Items done when progress was last printed
+}
+
+// NewConsoleObserver creates an observer printing to w
+func NewConsoleObserver(w io.Writer) *ConsoleObserver {
+	return &ConsoleObserver{w: w}
+}
+
+// ScanStarted prints the scan header
+func (c *ConsoleObserver) ScanStarted(info ScanInfo) {
+	c.kind = info.Kind
+	c.pingOnly = info.PingOnly
+	c.lastProgress = 0
+
+	if info.Kind == ScanKindStealthy {
+		fmt.Fprintf(c.w, "\n🎯 TARGET: %s", info.Target)
+		if info.Hostname != "" {
+			fmt.Fprintf(c.w, " (%s)", info.Hostname)
+		}
+		fmt.Fprintf(c.w, "\n")
+		fmt.Fprintf(c.w, "🔍 Scanning %d ports (range: %s, protocol: %s)\n", info.Ports, info.PortRange, info.Protocol)
+		if info.ScanType != "" {
+			fmt.Fprintf(c.w, "🧪 Scan type: %s\n", scanTypeLabel(info.ScanType))
+		}
+		fmt.Fprintf(c.w, "⚙️  Threads: %d | Timeout: %v | Timing: %s\n", info.Threads, info.Timeout, info.Timing)
+		fmt.Fprintln(c.w)
+		return
+	}
+
+	if info.PingOnly {
+		fmt.Fprintf(c.w, "\n🔍 Starting host discovery: %s\n", info.Target)
+		fmt.Fprintf(c.w, "📊 Hosts to ping: %d\n", info.Hosts)
+		fmt.Fprintf(c.w, "⏱️  Timeout: %v | Timing: %s\n\n", info.Timeout, info.Timing)
+		return
+	}
+	fmt.Fprintf(c.w, "\n🔍 Starting network scan: %s\n", info.Target)
+	fmt.Fprintf(c.w, "📊 Hosts to scan: %d\n", info.Hosts)
+	fmt.Fprintf(c.w, "🔌 Ports per host: %d (%s)\n", info.Ports, info.Protocol)
+	fmt.Fprintf(c.w, "⚙️  Threads: %d | Timing: %s\n", info.Threads, info.Timing)
+	fmt.Fprintf(c.w, "⏱️  Timeout: %v\n\n", info.Timeout)
+}
+
+// HostStarted is not shown on the console
+func (c *ConsoleObserver) HostStarted(ip string) {}
+
+// HostFinished prints live hosts of a network scan
+func (c *ConsoleObserver) HostFinished(result HostScanResult) {
+	if c.kind != ScanKindNetwork || !result.IsAlive {
+		return
+	}
+	if c.pingOnly {
+		fmt.Fprintf(c.w, "✅ %s - up (%s)\n", result.IP, result.Discovery.Reason)
+		return
+	}
+	fmt.Fprintf(c.w, "✅ %s - %d open port(s)\n", result.IP, len(result.OpenPorts))
+}
+
+// PortScanned prints open ports of a single-host scan
+func (c *ConsoleObserver) PortScanned(result PortResult) {
+	if c.kind == ScanKindStealthy && result.State == StateOpen {
+		fmt.Fprintf(c.w, "✅ Port %d/%s \t%s \t%s\n",
+			result.Port,
+			result.Protocol,
+			result.State,
+			result.Service)
+	}
+}
+
+// Progress prints single-host scan progress every 5% (at least 100 ports)
+func (c *ConsoleObserver) Progress(progress ScanProgress) {
+	if c.kind != ScanKindStealthy || progress.Total == 0 || progress.Done == progress.Total {
+		return
+	}
+
+	interval := progress.Total / 20
+	if interval < 100 {
+		interval = 100
+	}
+	if progress.Done-c.lastProgress < interval {
+		return
+	}
+	c.lastProgress = progress.Done
+
+	fmt.Fprintf(c.w, "⏳ Progress: %.0f%% (%d/%d %s scanned)", progress.Percent, progress.Done, progress.Total, progress.Unit)
+	if progress.ETA >= time.Second {
+		fmt.Fprintf(c.w, " - ETA %v", progress.ETA.Round(time.Second))
+	}
+	fmt.Fprintln(c.w)
+}
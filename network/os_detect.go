@@ -0,0 +1,274 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// osFingerprintData is the embedded TCP/IP stack signature database
+//
+//go:embed data/os-fingerprints.txt
+var osFingerprintData string
+
+// OSMatch is the operating system guessed for a host, with the evidence
+// the guess is based on
+type OSMatch struct {
+	Name       string   `json:"name"`       // e.g. "Linux 5.x-6.x"
+	Family     string   `json:"family"`     // e.g. "Linux", "Windows"
+	Confidence int      `json:"confidence"` // 0-100
+	Evidence   []string `json:"evidence"`   // e.g. "ttl 64", "OpenSSH on 22/tcp: Ubuntu"
+}
+
+// StackFingerprint holds what the host's TCP/IP stack revealed: the TTL of
+// its replies and the TCP window of its SYN-ACKs (0 when unknown)
+type StackFingerprint struct {
+	TTL    int
+	Window int
+}
+
+// osSignature is a row of the stack signature database
+type osSignature struct {
+	ttl    int
+	window int // 0 matches any window
+	family string
+	name   string
+}
+
+// osSignatures returns the parsed embedded database
+var osSignatures = sync.OnceValue(func() []osSignature {
+	return parseOSSignatures(osFingerprintData)
+})
+
+// parseOSSignatures parses "<ttl> <window|*> <family> <name>" lines,
+// skipping comments and malformed lines
+func parseOSSignatures(data string) []osSignature {
+	var signatures []osSignature
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		ttl, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		window := 0
+		if fields[1] != "*" {
+			if window, err = strconv.Atoi(fields[1]); err != nil {
+				continue
+			}
+		}
+		signatures = append(signatures, osSignature{
+			ttl:    ttl,
+			window: window,
+			family: fields[2],
+			name:   strings.Join(fields[3:], " "),
+		})
+	}
+	return signatures
+}
+
+// matchStack returns the first signature matching a stack fingerprint
+func matchStack(stack StackFingerprint) (osSignature, bool) {
+	if stack.TTL <= 0 {
+		return osSignature{}, false
+	}
+	ttl := initialTTL(stack.TTL)
+	for _, signature := range osSignatures() {
+		if signature.ttl == ttl && (signature.window == 0 || signature.window == stack.Window) {
+			return signature, true
+		}
+	}
+	return osSignature{}, false
+}
+
+// Weights of the OS evidence. A guess reaches 100% confidence with 100
+// points of agreeing evidence.
+const (
+	osWeightStack   = 50 // TTL and window match a specific signature
+	osWeightTTL     = 25 // TTL only
+	osWeightService = 35 // OS named by a service signature
+	osWeightBanner  = 30 // Distribution or vendor named in a banner
+	osWeightPorts   = 15 // Ports typical of an OS are open
+)
+
+// osBannerKeywords map the words found in banners to the OS family and the
+// name they reveal
+var osBannerKeywords = []struct {
+	keyword string
+	family  string
+	name    string
+}{
+	{"ubuntu", "Linux", "Linux (Ubuntu)"},
+	{"debian", "Linux", "Linux (Debian)"},
+	{"raspbian", "Linux", "Linux (Raspbian)"},
+	{"centos", "Linux", "Linux (CentOS)"},
+	{"red hat", "Linux", "Linux (Red Hat)"},
+	{"rhel", "Linux", "Linux (Red Hat)"},
+	{"fedora", "Linux", "Linux (Fedora)"},
+	{"alpine", "Linux", "Linux (Alpine)"},
+	{"suse", "Linux", "Linux (SUSE)"},
+	{"linux", "Linux", "Linux"},
+	{"microsoft", "Windows", "Windows"},
+	{"windows", "Windows", "Windows"},
+	{"win32", "Windows", "Windows"},
+	{"win64", "Windows", "Windows"},
+	{"freebsd", "FreeBSD", "FreeBSD"},
+	{"openbsd", "OpenBSD", "OpenBSD"},
+	{"darwin", "macOS", "macOS"},
+	{"mac os", "macOS", "macOS"},
+	{"cisco", "IOS", "Cisco IOS"},
+}
+
+// osPortProfiles are ports whose being open hints at an OS family
+var osPortProfiles = []struct {
+	family string
+	ports  []int
+}{
+	{"Windows", []int{135, 139, 445, 3389, 5985}},
+	{"macOS", []int{548, 3283, 7000}},
+}
+
+// guessOS combines the stack fingerprint and the open ports of a host into
+// an OS guess. It returns nil without evidence.
+func guessOS(stack StackFingerprint, openPorts []PortResult) *OSMatch {
+	scores := make(map[string]int)
+	names := make(map[string]string) // Most specific name per family
+	evidence := make(map[string][]string)
+	add := func(family, name string, weight int, reason string) {
+		scores[family] += weight
+		evidence[family] = append(evidence[family], reason)
+		if names[family] == "" || names[family] == family {
+			names[family] = name
+		}
+	}
+
+	if signature, ok := matchStack(stack); ok {
+		if signature.window != 0 {
+			add(signature.family, signature.name, osWeightStack, fmt.Sprintf("ttl %d, window %d", stack.TTL, stack.Window))
+		} else {
+			add(signature.family, signature.family, osWeightTTL, fmt.Sprintf("ttl %d", stack.TTL))
+		}
+	}
+
+	// One piece of evidence per port: the OS of its service signature, else
+	// a keyword of its banner. The keyword still refines the name.
+	for _, port := range openPorts {
+		text := strings.ToLower(port.Banner + " " + port.Product + " " + port.ExtraInfo)
+		family, name, weight, clue := "", "", 0, ""
+		for _, keyword := range osBannerKeywords {
+			if strings.Contains(text, keyword.keyword) {
+				family, name, weight, clue = keyword.family, keyword.name, osWeightBanner, keyword.keyword
+				break
+			}
+		}
+		if port.OSType != "" {
+			if osFamily(port.OSType) != family {
+				name = osFamily(port.OSType)
+			}
+			family, weight, clue = osFamily(port.OSType), osWeightService, port.OSType
+		}
+		if family != "" {
+			add(family, name, weight, fmt.Sprintf("%s on %d/%s: %s", serviceDescription(port), port.Port, port.Protocol, clue))
+		}
+	}
+
+	for _, profile := range osPortProfiles {
+		var open []string
+		for _, port := range openPorts {
+			for _, profilePort := range profile.ports {
+				if port.Protocol == ProtocolTCP && port.Port == profilePort {
+					open = append(open, strconv.Itoa(port.Port))
+				}
+			}
+		}
+		if len(open) > 0 {
+			add(profile.family, profile.family, osWeightPorts, "open ports "+strings.Join(open, ","))
+		}
+	}
+
+	if len(scores) == 0 {
+		return nil
+	}
+
+	families := make([]string, 0, len(scores))
+	total := 0
+	for family, score := range scores {
+		families = append(families, family)
+		total += score
+	}
+	sort.Slice(families, func(i, j int) bool {
+		if scores[families[i]] != scores[families[j]] {
+			return scores[families[i]] > scores[families[j]]
+		}
+		return families[i] < families[j]
+	})
+
+	best := families[0]
+	// Share of the evidence agreeing with the guess, scaled by how much
+	// evidence there is
+	confidence := scores[best] * min(total, 100) / total
+	return &OSMatch{
+		Name:       names[best],
+		Family:     best,
+		Confidence: min(confidence, 100),
+		Evidence:   evidence[best],
+	}
+}
+
+// osFamily normalizes the OS named by a service signature to a family
+func osFamily(osType string) string {
+	lower := strings.ToLower(osType)
+	for _, keyword := range osBannerKeywords {
+		if strings.Contains(lower, keyword.keyword) {
+			return keyword.family
+		}
+	}
+	return osType
+}
+
+// serviceDescription names the software of a port for the OS evidence
+func serviceDescription(port PortResult) string {
+	if port.Product != "" {
+		return port.Product
+	}
+	return port.Service
+}
+
+// DetectOS guesses the operating system of ip from the TTL of its ICMP
+// echo replies and the banners of its open ports, typically the OpenPorts
+// of a HostScanResult. A SYN-ACK seen by a raw scan gives the window too
+// and spares the echo. Without either (no privileges, filtered) the guess
+// relies on the banners alone; nil means no evidence at all.
+func DetectOS(ctx context.Context, ip string, openPorts []PortResult, timeout time.Duration) *OSMatch {
+	var stack StackFingerprint
+	for _, port := range openPorts {
+		if port.TTL > 0 && port.Window > 0 {
+			stack = StackFingerprint{TTL: port.TTL, Window: port.Window}
+			break
+		}
+	}
+	if stack.TTL > 0 {
+		return guessOS(stack, openPorts)
+	}
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		if reply, err := icmpEcho(ctx, addr, timeout); err == nil {
+			stack.TTL = reply.TTL
+		}
+	}
+	return guessOS(stack, openPorts)
+}
+
+// osSummary describes an OS guess for the text reports
+func osSummary(match *OSMatch) string {
+	return fmt.Sprintf("%s (%d%% confidence: %s)", match.Name, match.Confidence, strings.Join(match.Evidence, "; "))
+}
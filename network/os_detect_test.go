@@ -0,0 +1,119 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEmbeddedOSSignatures(t *testing.T) {
+	signatures := osSignatures()
+	if len(signatures) < 10 {
+		t.Fatalf("got %d OS signatures, want the embedded database", len(signatures))
+	}
+	for _, signature := range signatures {
+		if initialTTL(signature.ttl) != signature.ttl || signature.family == "" || signature.name == "" {
+			t.Errorf("malformed signature %+v", signature)
+		}
+	}
+}
+
+func TestParseOSSignatures(t *testing.T) {
+	got := parseOSSignatures("# comment\n64 5840 Linux Linux 2.4-2.6\n128 * Windows Windows\nbad * X Y\n64 x Linux L\n255 4128 IOS\n")
+	want := []osSignature{
+		{ttl: 64, window: 5840, family: "Linux", name: "Linux 2.4-2.6"},
+		{ttl: 128, family: "Windows", name: "Windows"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseOSSignatures = %+v, want %+v", got, want)
+	}
+}
+
+func TestInitialTTL(t *testing.T) {
+	tests := map[int]int{1: 32, 32: 32, 50: 64, 64: 64, 113: 128, 128: 128, 240: 255, 255: 255, 300: 255}
+	for ttl, want := range tests {
+		if got := initialTTL(ttl); got != want {
+			t.Errorf("initialTTL(%d) = %d, want %d", ttl, got, want)
+		}
+	}
+}
+
+func TestGuessOS(t *testing.T) {
+	ubuntuSSH := PortResult{Port: 22, Protocol: "tcp", Service: "ssh", Product: "OpenSSH", OSType: "Linux",
+		ExtraInfo: "Ubuntu 3ubuntu13.5; protocol 2.0", Banner: "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13.5"}
+	iis := PortResult{Port: 80, Protocol: "tcp", Service: "http", Product: "Microsoft IIS httpd", OSType: "Windows"}
+	rdp := PortResult{Port: 3389, Protocol: "tcp", Service: "ms-wbt-server"}
+
+	tests := []struct {
+		name  string
+		stack StackFingerprint
+		ports []PortResult
+		want  *OSMatch
+	}{
+		{"no evidence", StackFingerprint{}, []PortResult{{Port: 8080, Protocol: "tcp", Service: "http"}}, nil},
+		{"ttl only", StackFingerprint{TTL: 57}, nil,
+			&OSMatch{Name: "Linux", Family: "Linux", Confidence: 25, Evidence: []string{"ttl 57"}}},
+		{"stack signature", StackFingerprint{TTL: 64, Window: 65160}, nil,
+			&OSMatch{Name: "Linux 5.x-6.x", Family: "Linux", Confidence: 50, Evidence: []string{"ttl 64, window 65160"}}},
+		{"ttl and banner", StackFingerprint{TTL: 63}, []PortResult{ubuntuSSH},
+			&OSMatch{Name: "Linux (Ubuntu)", Family: "Linux", Confidence: 60,
+				Evidence: []string{"ttl 63", "OpenSSH on 22/tcp: Linux"}}},
+		{"windows services", StackFingerprint{TTL: 127}, []PortResult{iis, rdp},
+			&OSMatch{Name: "Windows", Family: "Windows", Confidence: 75,
+				Evidence: []string{"ttl 127", "Microsoft IIS httpd on 80/tcp: Windows", "open ports 3389"}}},
+		{"conflicting evidence", StackFingerprint{TTL: 64}, []PortResult{iis, rdp},
+			&OSMatch{Name: "Windows", Family: "Windows", Confidence: 50,
+				Evidence: []string{"Microsoft IIS httpd on 80/tcp: Windows", "open ports 3389"}}},
+	}
+	for _, tt := range tests {
+		if got := guessOS(tt.stack, tt.ports); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: guessOS = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGuessOSConfidenceCap(t *testing.T) {
+	var ports []PortResult
+	for port := 1; port <= 5; port++ {
+		ports = append(ports, PortResult{Port: port, Protocol: "tcp", Banner: "Debian GNU/Linux"})
+	}
+	match := guessOS(StackFingerprint{TTL: 64, Window: 29200}, ports)
+	if match == nil || match.Confidence != 100 || match.Name != "Linux 3.x-4.x" {
+		t.Errorf("guessOS = %+v, want Linux 3.x-4.x at 100%%", match)
+	}
+}
+
+func TestICMPChecksum(t *testing.T) {
+	// Echo request id 1, seq 1, no payload: 0x0800 + 0x0001 + 0x0001
+	message := icmpEchoMessage(false, 1, 1, nil)
+	if got := message[2:4]; !reflect.DeepEqual(got, []byte{0xf7, 0xfd}) {
+		t.Errorf("checksum = %x, want f7fd", got)
+	}
+	if icmpChecksum(message) != 0 {
+		t.Error("a message with its checksum does not sum to zero")
+	}
+	if v6 := icmpEchoMessage(true, 1, 1, []byte("x")); v6[0] != icmpv6EchoRequest || v6[2] != 0 || v6[3] != 0 {
+		t.Errorf("ICMPv6 echo = %x, want type 128 and the checksum left to the kernel", v6)
+	}
+}
+
+func TestICMPEchoLoopback(t *testing.T) {
+	reply, err := icmpEcho(context.Background(), netip.MustParseAddr("127.0.0.1"), time.Second)
+	if errors.Is(err, ErrICMPUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Skipf("ICMP sockets unavailable (no ping_group_range or CAP_NET_RAW): %v", err)
+	}
+	if reply.TTL != 64 {
+		t.Errorf("loopback echo TTL = %d, want 64", reply.TTL)
+	}
+
+	match := DetectOS(context.Background(), "127.0.0.1", nil, time.Second)
+	if match == nil || match.Family != "Linux" {
+		t.Errorf("DetectOS(127.0.0.1) = %+v, want Linux from the TTL", match)
+	}
+}
@@ -0,0 +1,99 @@
+package network
+
+import (
+	"bufio"
+	_ "embed"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// portFrequencyData is the embedded port frequency database
+//
+//go:embed data/port-frequencies.txt
+var portFrequencyData string
+
+// portFrequencies maps "port/protocol" to the fraction of hosts on which
+// the port is open, loaded on first use
+var portFrequencies = sync.OnceValue(func() map[string]float64 {
+	return parsePortFrequencies(portFrequencyData)
+})
+
+// parsePortFrequencies parses lines of the form
+// "<service> <port>/<protocol> <frequency>", skipping comments and
+// malformed lines
+func parsePortFrequencies(data string) map[string]float64 {
+	frequencies := make(map[string]float64)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		frequency, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			continue
+		}
+		frequencies[fields[1]] = frequency
+	}
+	return frequencies
+}
+
+// PortFrequency returns how often a port is found open, between 0 and 1.
+// Ports missing from the embedded database return 0.
+func PortFrequency(protocol string, port int) float64 {
+	return portFrequencies()[strconv.Itoa(port)+"/"+protocol]
+}
+
+// TopPorts returns the n ports most likely to be open for a protocol,
+// most frequent first. Ports without a known frequency follow in ascending
+// order, so well-known ports come before registered ones.
+func TopPorts(protocol string, n int) []int {
+	if n > 65535 {
+		n = 65535
+	}
+
+	ranked := rankPorts(protocol, databasePorts(protocol))
+	if len(ranked) > n {
+		return ranked[:n]
+	}
+
+	seen := make(map[int]bool, n)
+	for _, port := range ranked {
+		seen[port] = true
+	}
+	for port := 1; len(ranked) < n; port++ {
+		if !seen[port] {
+			ranked = append(ranked, port)
+		}
+	}
+	return ranked
+}
+
+// databasePorts returns the ports of a protocol listed in the database
+func databasePorts(protocol string) []int {
+	var ports []int
+	suffix := "/" + protocol
+	for key := range portFrequencies() {
+		if number, found := strings.CutSuffix(key, suffix); found {
+			port, _ := strconv.Atoi(number)
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// rankPorts returns a copy of ports ordered by decreasing frequency, ties
+// in ascending order
+func rankPorts(protocol string, ports []int) []int {
+	ports = append([]int(nil), ports...)
+	sort.SliceStable(ports, func(i, j int) bool {
+		fi, fj := PortFrequency(protocol, ports[i]), PortFrequency(protocol, ports[j])
+		if fi != fj {
+			return fi > fj
+		}
+		return ports[i] < ports[j]
+	})
+	return ports
+}
@@ -0,0 +1,68 @@
+package network
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParsePortFrequencies(t *testing.T) {
+	frequencies := parsePortFrequencies("# comment\nhttp\t80/tcp\t0.5\nbad 81/tcp x\nshort 82/tcp\n\ndns 53/udp 0.25\n")
+	if len(frequencies) != 2 || frequencies["80/tcp"] != 0.5 || frequencies["53/udp"] != 0.25 {
+		t.Errorf("parsePortFrequencies = %v, want 80/tcp and 53/udp", frequencies)
+	}
+}
+
+func TestPortFrequencyDatabase(t *testing.T) {
+	for _, protocol := range []string{ProtocolTCP, ProtocolUDP} {
+		if got := len(databasePorts(protocol)); got != 100 {
+			t.Errorf("%s ports in the database = %d, want 100", protocol, got)
+		}
+	}
+
+	if PortFrequency(ProtocolTCP, 80) <= PortFrequency(ProtocolTCP, 22) {
+		t.Error("80/tcp should be more frequent than 22/tcp")
+	}
+	if PortFrequency(ProtocolUDP, 161) == 0 || PortFrequency(ProtocolTCP, 161) != 0 {
+		t.Error("161 should only be listed for UDP")
+	}
+}
+
+func TestTopPorts(t *testing.T) {
+	tests := []struct {
+		protocol string
+		n        int
+		want     string
+	}{
+		{ProtocolTCP, 3, "[80 23 443]"},
+		{ProtocolUDP, 3, "[631 161 137]"},
+		{ProtocolTCP, 0, "[]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(TopPorts(tt.protocol, tt.n)); got != tt.want {
+			t.Errorf("TopPorts(%s, %d) = %s, want %s", tt.protocol, tt.n, got, tt.want)
+		}
+	}
+
+	// Past the database, unranked ports follow in ascending order
+	top := TopPorts(ProtocolTCP, 103)
+	if fmt.Sprint(top[100:]) != "[1 2 3]" {
+		t.Errorf("TopPorts(tcp, 103) tail = %v, want [1 2 3]", top[100:])
+	}
+	if len(TopPorts(ProtocolTCP, 70000)) != 65535 {
+		t.Error("TopPorts should cap at 65535 ports")
+	}
+}
+
+func TestRankPorts(t *testing.T) {
+	ports := []int{9, 5000, 22, 80, 7}
+	got := rankPorts(ProtocolTCP, ports)
+	if fmt.Sprint(got) != "[80 22 5000 7 9]" {
+		t.Errorf("rankPorts = %v, want [80 22 5000 7 9]", got)
+	}
+	if fmt.Sprint(ports) != "[9 5000 22 80 7]" {
+		t.Errorf("rankPorts modified its input: %v", ports)
+	}
+	if got := rankPorts(ProtocolUDP, nil); len(got) != 0 {
+		t.Errorf("rankPorts(nil) = %v, want no ports", got)
+	}
+}
@@ -1,399 +1,531 @@
-package network
-
-import (
-	"fmt"
-	"net"
-	"sort"
-	"strings"
-	"sync"
-	"time"
-)
-
-// PortScanResult represents the result of a port scan
-type PortScanResult struct {
-	IP       string
-	Port     int
-	IsOpen   bool
-	Service  string
-	Banner   string
-	ScanTime time.Duration
-}
-
-// HostScanResult represents the complete result of a host scan
-type HostScanResult struct {
-	IP         string
-	IsAlive    bool
-	OpenPorts  []PortScanResult
-	OS         string
-	Hostname   string
-	TotalPorts int
-	ScanTime   time.Duration
-}
-
-// NetworkScanConfig network scan configuration
-type NetworkScanConfig struct {
-	Network          string        // CIDR notation (e.g., 192.168.1.0/24)
-	PortRange        string        // Port range (e.g., "1-1024" or "all")
-	Timeout          time.Duration // Timeout per port
-	Threads          int           // Number of parallel threads
-	ServiceDetection bool          // Detect services
-	OSDetection      bool          // Detect OS (limited)
-}
-
-// Map of common services by port
-var commonServices = map[int]string{
-	20:    "FTP-DATA",
-	21:    "FTP",
-	22:    "SSH",
-	23:    "Telnet",
-	25:    "SMTP",
-	53:    "DNS",
-	80:    "HTTP",
-	110:   "POP3",
-	143:   "IMAP",
-	443:   "HTTPS",
-	445:   "SMB",
-	3306:  "MySQL",
-	3389:  "RDP",
-	5432:  "PostgreSQL",
-	5900:  "VNC",
-	8080:  "HTTP-Proxy",
-	8443:  "HTTPS-Alt",
-	27017: "MongoDB",
-	6379:  "Redis",
-}
-
-// ParseCIDR converts CIDR to a list of IPs
-func ParseCIDR(cidr string) ([]string, error) {
-	ip, ipNet, err := net.ParseCIDR(cidr)
-	if err != nil {
-		return nil, fmt.Errorf("invalid CIDR: %v", err)
-	}
-
-	var ips []string
-	for ip := ip.Mask(ipNet.Mask); ipNet.Contains(ip); incIP(ip) {
-		ips = append(ips, ip.String())
-	}
-
-	// Remove network address and broadcast address
-	if len(ips) > 2 {
-		ips = ips[1 : len(ips)-1]
-	}
-
-	return ips, nil
-}
-
-// incIP increments an IP address
-func incIP(ip net.IP) {
-	for j := len(ip) - 1; j >= 0; j-- {
-		ip[j]++
-		if ip[j] > 0 {
-			break
-		}
-	}
-}
-
-// ParsePortRange converts range string to list of ports
-func ParsePortRange(portRange string) []int {
-	if portRange == "all" || portRange == "" {
-		// Full scan would be too slow, let's use common ports
-		var ports []int
-		for port := range commonServices {
-			ports = append(ports, port)
-		}
-		// Add some additional important ports
-		additionalPorts := []int{8000, 8008, 8888, 9090, 9200, 9300}
-		ports = append(ports, additionalPorts...)
-		sort.Ints(ports)
-		return ports
-	}
-
-	// Parse format: "1-1024" ou "80,443,8080"
-	var ports []int
-
-	if strings.Contains(portRange, "-") {
-		parts := strings.Split(portRange, "-")
-		if len(parts) == 2 {
-			var start, end int
-			fmt.Sscanf(parts[0], "%d", &start)
-			fmt.Sscanf(parts[1], "%d", &end)
-
-			if start > 0 && end <= 65535 && start <= end {
-				for i := start; i <= end; i++ {
-					ports = append(ports, i)
-				}
-			}
-		}
-	} else if strings.Contains(portRange, ",") {
-		parts := strings.Split(portRange, ",")
-		for _, p := range parts {
-			var port int
-			if _, err := fmt.Sscanf(strings.TrimSpace(p), "%d", &port); err == nil {
-				if port > 0 && port <= 65535 {
-					ports = append(ports, port)
-				}
-			}
-		}
-	} else {
-		var port int
-		if _, err := fmt.Sscanf(portRange, "%d", &port); err == nil {
-			if port > 0 && port <= 65535 {
-				ports = append(ports, port)
-			}
-		}
-	}
-
-	return ports
-}
-
-// IsHostAlive checks if the host is alive (TCP ping)
-func IsHostAlive(ip string, timeout time.Duration) bool {
-	// Try to connect to common ports
-	commonPorts := []int{80, 443, 22, 21, 25, 3389}
-
-	for _, port := range commonPorts {
-		address := fmt.Sprintf("%s:%d", ip, port)
-		conn, err := net.DialTimeout("tcp", address, timeout)
-		if err == nil {
-			conn.Close()
-			return true
-		}
-	}
-
-	return false
-}
-
-// ScanPort scans a specific port on an IP
-func ScanPort(ip string, port int, timeout time.Duration, serviceDetection bool) PortScanResult {
-	result := PortScanResult{
-		IP:      ip,
-		Port:    port,
-		IsOpen:  false,
-		Service: "Unknown",
-	}
-
-	start := time.Now()
-	address := fmt.Sprintf("%s:%d", ip, port)
-
-	conn, err := net.DialTimeout("tcp", address, timeout)
-	result.ScanTime = time.Since(start)
-
-	if err != nil {
-		return result
-	}
-	defer conn.Close()
-
-	result.IsOpen = true
-
-	// Identify service
-	if service, exists := commonServices[port]; exists {
-		result.Service = service
-	}
-
-	// Service detection through banner grabbing
-	if serviceDetection {
-		conn.SetReadDeadline(time.Now().Add(timeout))
-		buffer := make([]byte, 1024)
-		n, err := conn.Read(buffer)
-		if err == nil && n > 0 {
-			result.Banner = strings.TrimSpace(string(buffer[:n]))
-			// Tentar identificar serviço pelo banner
-			result.Service = identifyServiceByBanner(result.Banner, result.Service)
-		}
-	}
-
-	return result
-}
-
-// identifyServiceByBanner tries to identify service by banner
-func identifyServiceByBanner(banner, currentService string) string {
-	banner = strings.ToLower(banner)
-
-	if strings.Contains(banner, "ssh") {
-		return "SSH"
-	} else if strings.Contains(banner, "ftp") {
-		return "FTP"
-	} else if strings.Contains(banner, "http") || strings.Contains(banner, "html") {
-		return "HTTP"
-	} else if strings.Contains(banner, "smtp") || strings.Contains(banner, "mail") {
-		return "SMTP"
-	} else if strings.Contains(banner, "mysql") {
-		return "MySQL"
-	} else if strings.Contains(banner, "redis") {
-		return "Redis"
-	}
-
-	return currentService
-}
-
-// ScanHost scans all ports of a host
-func ScanHost(ip string, ports []int, config NetworkScanConfig) HostScanResult {
-	result := HostScanResult{
-		IP:         ip,
-		IsAlive:    false,
-		OpenPorts:  []PortScanResult{},
-		TotalPorts: len(ports),
-	}
-
-	start := time.Now()
-
-	// Check if host is alive
-	if !IsHostAlive(ip, config.Timeout) {
-		result.ScanTime = time.Since(start)
-		return result
-	}
-
-	result.IsAlive = true
-
-	// Resolver hostname
-	names, err := net.LookupAddr(ip)
-	if err == nil && len(names) > 0 {
-		result.Hostname = names[0]
-	}
-
-	// Scan de portas com pool de workers
-	var wg sync.WaitGroup
-	portChan := make(chan int, len(ports))
-	resultChan := make(chan PortScanResult, len(ports))
-
-	// Workers
-	for i := 0; i < config.Threads; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for port := range portChan {
-				scanResult := ScanPort(ip, port, config.Timeout, config.ServiceDetection)
-				if scanResult.IsOpen {
-					resultChan <- scanResult
-				}
-			}
-		}()
-	}
-
-	// Enviar portas para scan
-	go func() {
-		for _, port := range ports {
-			portChan <- port
-		}
-		close(portChan)
-	}()
-
-	// Aguardar conclusão
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
-
-	// Coletar resultados
-	for scanResult := range resultChan {
-		result.OpenPorts = append(result.OpenPorts, scanResult)
-	}
-
-	// Ordenar por número de porta
-	sort.Slice(result.OpenPorts, func(i, j int) bool {
-		return result.OpenPorts[i].Port < result.OpenPorts[j].Port
-	})
-
-	result.ScanTime = time.Since(start)
-	return result
-}
-
-// ScanNetwork escaneia toda a rede
-func ScanNetwork(config NetworkScanConfig) ([]HostScanResult, error) {
-	// Parse CIDR
-	ips, err := ParseCIDR(config.Network)
-	if err != nil {
-		return nil, err
-	}
-
-	// Parse portas
-	ports := ParsePortRange(config.PortRange)
-	if len(ports) == 0 {
-		return nil, fmt.Errorf("no valid ports specified")
-	}
-
-	fmt.Printf("\n🔍 Starting network scan: %s\n", config.Network)
-	fmt.Printf("📊 Hosts to scan: %d\n", len(ips))
-	fmt.Printf("🔌 Ports per host: %d\n", len(ports))
-	fmt.Printf("⚙️  Threads: %d\n", config.Threads)
-	fmt.Printf("⏱️  Timeout: %v\n\n", config.Timeout)
-
-	var results []HostScanResult
-	var resultsMutex sync.Mutex
-	var wg sync.WaitGroup
-
-	// Semáforo para limitar hosts simultâneos
-	semaphore := make(chan struct{}, 10)
-
-	for _, ip := range ips {
-		wg.Add(1)
-		semaphore <- struct{}{} // Adquirir
-
-		go func(targetIP string) {
-			defer wg.Done()
-			defer func() { <-semaphore }() // Liberar
-
-			result := ScanHost(targetIP, ports, config)
-
-			if result.IsAlive {
-				resultsMutex.Lock()
-				results = append(results, result)
-				resultsMutex.Unlock()
-
-				fmt.Printf("✅ %s - %d open port(s)\n", targetIP, len(result.OpenPorts))
-			}
-		}(ip)
-	}
-
-	wg.Wait()
-
-	return results, nil
-}
-
-// PrintScanResults prints scan results in a formatted way
-func PrintScanResults(results []HostScanResult) {
-	if len(results) == 0 {
-		fmt.Println("\n❌ No active hosts found on the network.")
-		return
-	}
-
-	fmt.Printf("\n\n" + strings.Repeat("=", 80) + "\n")
-	fmt.Printf("📊 NETWORK SCAN REPORT\n")
-	fmt.Printf(strings.Repeat("=", 80) + "\n\n")
-
-	totalOpenPorts := 0
-
-	for _, host := range results {
-		fmt.Printf("🖥️  HOST: %s", host.IP)
-		if host.Hostname != "" {
-			fmt.Printf(" (%s)", host.Hostname)
-		}
-		fmt.Printf("\n")
-		fmt.Printf("   Scan time: %v\n", host.ScanTime.Round(time.Millisecond))
-
-		if len(host.OpenPorts) == 0 {
-			fmt.Printf("   ⚠️  No open ports found\n\n")
-			continue
-		}
-
-		fmt.Printf("   🔓 Open ports: %d\n\n", len(host.OpenPorts))
-		fmt.Printf("   %-10s %-20s %-30s\n", "PORT", "SERVICE", "BANNER")
-		fmt.Printf("   " + strings.Repeat("-", 70) + "\n")
-
-		for _, port := range host.OpenPorts {
-			banner := port.Banner
-			if len(banner) > 28 {
-				banner = banner[:25] + "..."
-			}
-			fmt.Printf("   %-10d %-20s %-30s\n", port.Port, port.Service, banner)
-			totalOpenPorts++
-		}
-		fmt.Printf("\n")
-	}
-
-	fmt.Printf(strings.Repeat("=", 80) + "\n")
-	fmt.Printf("📈 SUMMARY:\n")
-	fmt.Printf("   Active hosts: %d\n", len(results))
-	fmt.Printf("   Total open ports: %d\n", totalOpenPorts)
-	fmt.Printf(strings.Repeat("=", 80) + "\n\n")
-}
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostScanResult represents the complete result of a host scan
+type HostScanResult struct {
+	IP                string           `json:"ip"`
+	IsAlive           bool             `json:"is_alive"`
+	OpenPorts         []PortScanResult `json:"open_ports"`
+	ClosedPorts       int              `json:"closed_ports"`
+	FilteredPorts     int              `json:"filtered_ports"`
+	OpenFilteredPorts int              `json:"open_filtered_ports"` // UDP ports that did not answer
+	OS                string           `json:"os,omitempty"`        // Name of OSMatch
+	OSMatch           *OSMatch         `json:"os_match,omitempty"`  // Set by OSDetection
+	Discovery         *HostDiscovery   `json:"discovery,omitempty"` // How the host was found up (reason user-set with SkipDiscovery)
+	Hostname          string           `json:"hostname,omitempty"`
+	MAC               string           `json:"mac,omitempty"`    // Hosts on directly attached links
+	Vendor            string           `json:"vendor,omitempty"` // Vendor of MAC (see MACVendor)
+	Protocols         []string         `json:"protocols"`        // Transport protocols scanned ("tcp", "udp")
+	TotalPorts        int              `json:"total_ports"`      // Ports times protocols
+	ScannedPorts      int              `json:"scanned_ports"`    // Ports actually probed (less than TotalPorts when interrupted)
+	Incomplete        bool             `json:"incomplete"`       // Scan was cancelled before every port was probed
+	StartTime         time.Time        `json:"start_time"`
+	ScanTime          time.Duration    `json:"-"` // Exported as scan_time_ms
+}
+
+// NetworkScanReport is the result of a whole network scan. Hosts holds the
+// live hosts; the counters tell whether every address of the network was
+// scanned.
+type NetworkScanReport struct {
+	Network      string           `json:"network"`
+	TotalHosts   int              `json:"total_hosts"`          // Addresses in the targets, after exclusions
+	ScannedHosts int              `json:"scanned_hosts"`        // Addresses fully scanned (less than TotalHosts when interrupted)
+	Incomplete   bool             `json:"incomplete"`           // Scan was cancelled before every address was scanned
+	PingOnly     bool             `json:"ping_only,omitempty"`  // Host discovery only, no port was scanned
+	Unresolved   []string         `json:"unresolved,omitempty"` // Hostnames that could not be resolved
+	Hosts        []HostScanResult `json:"hosts"`
+	StartTime    time.Time        `json:"start_time"`
+	ScanTime     time.Duration    `json:"-"` // Exported as scan_time_ms
+}
+
+// NetworkScanConfig network scan configuration
+type NetworkScanConfig struct {
+	Network           string        // Targets: comma-separated IPs, CIDRs (192.168.1.0/24, 2001:db8::/120), ranges (10.0.0.1-50) and hostnames
+	Exclude           string        // Targets skipped, same syntax as Network (optional)
+	TargetList        []string      // Extra targets, e.g. read with ReadTargetFile (optional)
+	ExcludeList       []string      // Extra exclusions, e.g. read with ReadTargetFile (optional)
+	PortRange         string        // Port specification (see ParsePortSpec, e.g., "22,80,8000-8100", "T:80,U:53" or "top100")
+	Timeout           time.Duration // Timeout per port (0: the Timing template's)
+	Threads           int           // Number of parallel threads (0: the Timing template's)
+	Timing            string        // Timing template, T0 to T5 or its name (see ParseTiming; "": T3)
+	ServiceDetection  bool          // Detect services
+	OSDetection       bool          // Guess the OS from TTL and banners (see DetectOS)
+	Discovery         []string      // Host discovery methods, combined (nil: DefaultDiscovery; see DiscoverHost)
+	DiscoveryTCPPorts []int         // Ports of the TCP SYN/ACK pings (nil: DefaultDiscoveryTCPPorts)
+	DiscoveryUDPPorts []int         // Ports of the UDP pings (nil: DefaultDiscoveryUDPPorts)
+	SkipDiscovery     bool          // Treat every target as up and scan it, like nmap -Pn (Discovery is ignored)
+	PingOnly          bool          // Only find the live hosts, like nmap -sn: no port scan (PortRange, Protocol and Prober are ignored)
+	Protocol          string        // "tcp" (default), "udp" or "both"
+	Observer          ScanObserver  // Receives progress events (nil for a silent scan)
+	Prober            Prober        // Probe strategy, overrides Protocol (nil: banner grab when ServiceDetection, else connect)
+}
+
+// commonServicePorts are the ports of the most common services, scanned by
+// the "common" port set
+var commonServicePorts = []int{
+	20, 21, 22, 23, 25, 53, 80, 110, 143, 443, 445,
+	3306, 3389, 5432, 5900, 6379, 8080, 8443, 27017,
+}
+
+// MaxIPv6PrefixBits is the largest number of host bits of an IPv6 prefix
+// that ParseCIDR enumerates (a /112, 65536 addresses). Larger prefixes such
+// as a /64 cannot be swept address by address.
+const MaxIPv6PrefixBits = 16
+
+// ParseCIDR converts CIDR to a list of IPs. IPv4 networks exclude their
+// network and broadcast addresses; IPv6 prefixes with more than
+// MaxIPv6PrefixBits host bits are rejected.
+func ParseCIDR(cidr string) ([]string, error) {
+	ip, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR: %v", err)
+	}
+
+	ones, bits := ipNet.Mask.Size()
+	isIPv6 := ip.To4() == nil
+	if isIPv6 && bits-ones > MaxIPv6PrefixBits {
+		return nil, fmt.Errorf("IPv6 prefix %s has 2^%d addresses, too many to enumerate (list the hosts explicitly or scan the neighbour-discovered hosts)", cidr, bits-ones)
+	}
+
+	var ips []string
+	for ip := ip.Mask(ipNet.Mask); ipNet.Contains(ip); incIP(ip) {
+		ips = append(ips, ip.String())
+	}
+
+	// Remove network address and broadcast address (IPv4 only)
+	if !isIPv6 && len(ips) > 2 {
+		ips = ips[1 : len(ips)-1]
+	}
+
+	return ips, nil
+}
+
+// incIP increments an IP address
+func incIP(ip net.IP) {
+	for j := len(ip) - 1; j >= 0; j-- {
+		ip[j]++
+		if ip[j] > 0 {
+			break
+		}
+	}
+}
+
+// IsHostAlive checks if the host is alive with the default discovery
+// methods (see DiscoverHost)
+func IsHostAlive(ip string, timeout time.Duration) bool {
+	return IsHostAliveContext(context.Background(), ip, timeout)
+}
+
+// IsHostAliveContext is like IsHostAlive but gives up as soon as ctx is cancelled
+func IsHostAliveContext(ctx context.Context, ip string, timeout time.Duration) bool {
+	return DiscoverHost(ctx, ip, NetworkScanConfig{Timeout: timeout}) != nil
+}
+
+// ScanPort scans a specific port on an IP
+func ScanPort(ip string, port int, timeout time.Duration, serviceDetection bool) PortScanResult {
+	return ScanPortContext(context.Background(), ip, port, timeout, serviceDetection)
+}
+
+// ScanPortContext is like ScanPort but aborts the connection and the banner
+// read when ctx is cancelled
+func ScanPortContext(ctx context.Context, ip string, port int, timeout time.Duration, serviceDetection bool) PortScanResult {
+	return defaultTCPProber(timeout, serviceDetection).Probe(ctx, ip, port)
+}
+
+// identifyServiceByBanner tries to identify service by banner
+func identifyServiceByBanner(banner, currentService string) string {
+	banner = strings.ToLower(banner)
+
+	if strings.Contains(banner, "ssh") {
+		return "ssh"
+	} else if strings.Contains(banner, "ftp") {
+		return "ftp"
+	} else if strings.Contains(banner, "http") || strings.Contains(banner, "html") {
+		return "http"
+	} else if strings.Contains(banner, "smtp") || strings.Contains(banner, "mail") {
+		return "smtp"
+	} else if strings.Contains(banner, "mysql") {
+		return "mysql"
+	} else if strings.Contains(banner, "redis") {
+		return "redis"
+	}
+
+	return currentService
+}
+
+// ScanHost scans all ports of a host
+func ScanHost(ip string, ports []int, config NetworkScanConfig) HostScanResult {
+	return ScanHostContext(context.Background(), ip, ports, config)
+}
+
+// ScanHostContext is like ScanHost but stops dispatching ports when ctx is
+// cancelled. Ports already being probed are drained and the partial result
+// is returned with Incomplete set. An invalid config.Protocol or
+// config.Timing falls back to TCP and DefaultTiming; use ParseProtocol and
+// ParseTiming to validate them beforehand.
+func ScanHostContext(ctx context.Context, ip string, ports []int, config NetworkScanConfig) HostScanResult {
+	protocol, err := ParseProtocol(config.Protocol)
+	if err != nil {
+		protocol = ProtocolTCP
+	}
+	timing, err := resolveTiming(config.Timing, config.Threads, config.Timeout)
+	if err != nil {
+		timing, _ = resolveTiming("", config.Threads, config.Timeout)
+	}
+	config.Threads, config.Timeout = timing.Parallelism, timing.Timeout
+	probers := scanProbers(config.Prober, protocol, defaultTCPProber(config.Timeout, config.ServiceDetection), timing)
+	return scanHost(ctx, ip, uniformPorts(ports), probers, config, timing, newScanEvents(config.Observer))
+}
+
+// scanHost scans one host at the pace of timing, reporting to events shared
+// by the whole scan. config.Threads and config.Timeout are those of timing.
+func scanHost(ctx context.Context, ip string, ports PortSpec, probers []Prober, config NetworkScanConfig, timing TimingTemplate, events *scanEvents) HostScanResult {
+	events.hostStarted(ip)
+
+	result := HostScanResult{
+		IP:         ip,
+		IsAlive:    false,
+		OpenPorts:  []PortScanResult{},
+		Protocols:  probersProtocols(probers),
+		TotalPorts: ports.Count(probers),
+	}
+
+	start := time.Now()
+	result.StartTime = start
+
+	// Check if host is alive, unless told to treat it as up
+	if config.SkipDiscovery {
+		result.Discovery = &HostDiscovery{Reason: "user-set"}
+	} else if result.Discovery = DiscoverHost(ctx, ip, config); result.Discovery == nil {
+		result.Incomplete = ctx.Err() != nil
+		result.ScanTime = time.Since(start)
+		events.hostFinished(result)
+		return result
+	}
+
+	result.IsAlive = true
+
+	// Resolver hostname
+	names, err := net.DefaultResolver.LookupAddr(ctx, ip)
+	if err == nil && len(names) > 0 {
+		result.Hostname = names[0]
+	}
+	if result.MAC = hostMAC(ip, result.Discovery); result.MAC != "" {
+		result.Vendor = MACVendor(result.MAC)
+	}
+
+	if config.PingOnly {
+		result.ScanTime = time.Since(start)
+		events.hostFinished(result)
+		return result
+	}
+
+	// Scan de portas com pool de workers
+	results := scanWithProbers(ctx, probers, timing, ip, ports, func(scanResult PortResult) {
+		events.portScanned(scanResult)
+	})
+
+	// Results come back sorted by port number and protocol
+	for _, scanResult := range results {
+		switch scanResult.State {
+		case StateOpen:
+			result.OpenPorts = append(result.OpenPorts, scanResult)
+		case StateClosed:
+			result.ClosedPorts++
+		case StateOpenFiltered:
+			result.OpenFilteredPorts++
+		default:
+			result.FilteredPorts++
+		}
+	}
+	result.ScannedPorts = len(results)
+	result.Incomplete = result.ScannedPorts < result.TotalPorts
+
+	if config.OSDetection && ctx.Err() == nil {
+		if result.OSMatch = DetectOS(ctx, ip, result.OpenPorts, config.Timeout); result.OSMatch != nil {
+			result.OS = result.OSMatch.Name
+		}
+	}
+
+	result.ScanTime = time.Since(start)
+	events.hostFinished(result)
+	return result
+}
+
+// ScanNetwork escaneia toda a rede
+func ScanNetwork(config NetworkScanConfig) ([]HostScanResult, error) {
+	return ScanNetworkContext(context.Background(), config)
+}
+
+// ScanNetworkContext is like ScanNetwork but stops starting new hosts when
+// ctx is cancelled. The hosts found so far are returned together with
+// ctx.Err(); hosts interrupted mid-scan have Incomplete set. Use
+// ScanNetworkReportContext to know how much of the network was scanned.
+func ScanNetworkContext(ctx context.Context, config NetworkScanConfig) ([]HostScanResult, error) {
+	report, err := ScanNetworkReportContext(ctx, config)
+	if report == nil {
+		return nil, err
+	}
+	return report.Hosts, err
+}
+
+// ScanNetworkReportContext scans the network like ScanNetworkContext and
+// returns a report with the scan-level counters. When ctx is cancelled the
+// partial report is returned with Incomplete set, together with ctx.Err().
+func ScanNetworkReportContext(ctx context.Context, config NetworkScanConfig) (*NetworkScanReport, error) {
+	// Targets are generated one at a time, so large networks use no memory
+	targets, err := NewTargetIterator(append([]string{config.Network}, config.TargetList...),
+		append([]string{config.Exclude}, config.ExcludeList...))
+	if err != nil {
+		return nil, err
+	}
+	target := targetDescription(config)
+
+	if _, err := ParseDiscovery(strings.Join(config.Discovery, ",")); err != nil {
+		return nil, err
+	}
+	timing, err := resolveTiming(config.Timing, config.Threads, config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	config.Threads, config.Timeout = timing.Parallelism, timing.Timeout
+
+	// Parse portas; a ping sweep has none
+	var ports PortSpec
+	var probers []Prober
+	if config.PingOnly {
+		if config.SkipDiscovery {
+			return nil, errors.New("a ping sweep cannot skip host discovery")
+		}
+	} else {
+		if ports, err = ParsePortSpec(config.PortRange); err != nil {
+			return nil, err
+		}
+		protocol, err := ParseProtocol(config.Protocol)
+		if err != nil {
+			return nil, err
+		}
+		probers = scanProbers(config.Prober, protocol, defaultTCPProber(config.Timeout, config.ServiceDetection), timing)
+		if ports.Count(probers) == 0 {
+			return nil, fmt.Errorf("port specification %q selects no %s ports", config.PortRange, strings.Join(probersProtocols(probers), "/"))
+		}
+	}
+
+	events := newScanEvents(config.Observer)
+	events.scanStarted(ScanInfo{
+		Kind:      ScanKindNetwork,
+		Target:    target,
+		Hosts:     targets.Count(),
+		Ports:     ports.Count(probers),
+		PortRange: config.PortRange,
+		Protocol:  strings.Join(probersProtocols(probers), "+"),
+		Threads:   config.Threads,
+		Timeout:   config.Timeout,
+		Timing:    timing.String(),
+		PingOnly:  config.PingOnly,
+	})
+
+	report := &NetworkScanReport{
+		Network:    target,
+		TotalHosts: targets.Count(),
+		PingOnly:   config.PingOnly,
+		StartTime:  time.Now(),
+	}
+	var resultsMutex sync.Mutex
+	var wg sync.WaitGroup
+
+	// Semáforo para limitar hosts simultâneos
+	semaphore := make(chan struct{}, timing.Hosts)
+
+	dispatched := 0
+
+dispatch:
+	for {
+		ip, ok := targets.Next(ctx)
+		if !ok {
+			break
+		}
+		select {
+		case semaphore <- struct{}{}: // Adquirir
+		case <-ctx.Done():
+			break dispatch
+		}
+		wg.Add(1)
+		dispatched++
+
+		go func(targetIP string) {
+			defer wg.Done()
+			defer func() { <-semaphore }() // Liberar
+
+			result := scanHost(ctx, targetIP, ports, probers, config, timing, events)
+
+			resultsMutex.Lock()
+			if result.IsAlive {
+				report.Hosts = append(report.Hosts, result)
+			}
+			if !result.Incomplete {
+				report.ScannedHosts++
+			}
+			done := report.ScannedHosts
+			resultsMutex.Unlock()
+
+			events.progress("hosts", done, report.TotalHosts)
+		}(ip)
+	}
+
+	wg.Wait()
+
+	// Hostnames that failed to resolve are not part of the scan
+	report.Unresolved = targets.Unresolved()
+	if ctx.Err() == nil {
+		report.TotalHosts = dispatched
+	} else {
+		report.TotalHosts -= len(report.Unresolved)
+	}
+
+	report.ScanTime = time.Since(report.StartTime)
+	report.Incomplete = report.ScannedHosts < report.TotalHosts
+	return report, ctx.Err()
+}
+
+// targetDescription names the scanned targets in reports: the Network
+// specification, followed by the size of the target list when there is one
+func targetDescription(config NetworkScanConfig) string {
+	switch {
+	case len(config.TargetList) == 0:
+		return config.Network
+	case config.Network == "":
+		return fmt.Sprintf("target list (%d entries)", len(config.TargetList))
+	default:
+		return fmt.Sprintf("%s + target list (%d entries)", config.Network, len(config.TargetList))
+	}
+}
+
+// PrintScanResults prints scan results in a formatted way
+func PrintScanResults(results []HostScanResult) {
+	FprintScanResults(os.Stdout, results)
+}
+
+// FprintScanResults writes scan results in a formatted way to w
+func FprintScanResults(w io.Writer, results []HostScanResult) {
+	fprintScanResults(w, results, nil)
+}
+
+// PrintNetworkScanReport prints a network scan report in a formatted way
+func PrintNetworkScanReport(report *NetworkScanReport) {
+	FprintNetworkScanReport(os.Stdout, report)
+}
+
+// FprintNetworkScanReport writes a network scan report in a formatted way
+// to w, warning when the scan did not cover the whole network
+func FprintNetworkScanReport(w io.Writer, report *NetworkScanReport) {
+	fprintScanResults(w, report.Hosts, report)
+}
+
+// fprintScanResults writes the live hosts and, when report is not nil, the
+// scan-level counters
+func fprintScanResults(w io.Writer, results []HostScanResult, report *NetworkScanReport) {
+	if report != nil && len(report.Unresolved) > 0 {
+		fmt.Fprintf(w, "\n⚠️  Failed to resolve: %s\n", strings.Join(report.Unresolved, ", "))
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintln(w, "\n❌ No active hosts found on the network.")
+		if report != nil && report.Incomplete {
+			fmt.Fprintf(w, "⚠️  Scan interrupted: %d of %d hosts scanned\n", report.ScannedHosts, report.TotalHosts)
+		}
+		return
+	}
+
+	pingOnly := report != nil && report.PingOnly
+	fmt.Fprintf(w, "\n\n"+strings.Repeat("=", 80)+"\n")
+	if pingOnly {
+		fmt.Fprintf(w, "📊 HOST DISCOVERY REPORT\n")
+	} else {
+		fmt.Fprintf(w, "📊 NETWORK SCAN REPORT\n")
+	}
+	fmt.Fprintf(w, strings.Repeat("=", 80)+"\n\n")
+
+	totalOpenPorts := 0
+
+	for _, host := range results {
+		fmt.Fprintf(w, "🖥️  HOST: %s", host.IP)
+		if host.Hostname != "" {
+			fmt.Fprintf(w, " (%s)", host.Hostname)
+		}
+		fmt.Fprintf(w, "\n")
+		fmt.Fprintf(w, "   Scan time: %v\n", host.ScanTime.Round(time.Millisecond))
+		if host.Discovery != nil {
+			fmt.Fprintf(w, "   Up: %s\n", discoverySummary(host.Discovery))
+		}
+		if host.MAC != "" {
+			fmt.Fprintf(w, "   MAC: %s", host.MAC)
+			if host.Vendor != "" {
+				fmt.Fprintf(w, " (%s)", host.Vendor)
+			}
+			fmt.Fprintf(w, "\n")
+		}
+		if pingOnly {
+			fmt.Fprintf(w, "\n")
+			continue
+		}
+		if host.OSMatch != nil {
+			fmt.Fprintf(w, "   OS: %s\n", osSummary(host.OSMatch))
+		}
+		fmt.Fprintf(w, "   Closed ports: %d | Filtered ports: %d", host.ClosedPorts, host.FilteredPorts)
+		if host.OpenFilteredPorts > 0 {
+			fmt.Fprintf(w, " | Open|filtered ports: %d", host.OpenFilteredPorts)
+		}
+		fmt.Fprintf(w, "\n")
+		if host.Incomplete {
+			fmt.Fprintf(w, "   ⚠️  Partial results: scan interrupted (%d/%d ports scanned)\n", host.ScannedPorts, host.TotalPorts)
+		}
+
+		if len(host.OpenPorts) == 0 {
+			fmt.Fprintf(w, "   ⚠️  No open ports found\n\n")
+			continue
+		}
+
+		fmt.Fprintf(w, "   🔓 Open ports: %d\n\n", len(host.OpenPorts))
+		fmt.Fprintf(w, "   %-10s %-20s %-30s\n", "PORT", "SERVICE", "VERSION/BANNER")
+		fmt.Fprintf(w, "   "+strings.Repeat("-", 70)+"\n")
+
+		for _, port := range host.OpenPorts {
+			banner := versionString(port)
+			if len(banner) > 28 {
+				banner = banner[:25] + "..."
+			}
+			fmt.Fprintf(w, "   %-10s %-20s %-30s\n", fmt.Sprintf("%d/%s", port.Port, port.Protocol), serviceLabel(port), banner)
+			for _, detail := range portDetails(port) {
+				fmt.Fprintf(w, "      %s\n", detail)
+			}
+			totalOpenPorts++
+		}
+		fmt.Fprintf(w, "\n")
+	}
+
+	fmt.Fprintln(w, strings.Repeat("=", 80))
+	fmt.Fprintf(w, "📈 SUMMARY:\n")
+	fmt.Fprintf(w, "   Active hosts: %d\n", len(results))
+	if !pingOnly {
+		fmt.Fprintf(w, "   Total open ports: %d\n", totalOpenPorts)
+	}
+	if report != nil {
+		fmt.Fprintf(w, "   Hosts scanned: %d of %d\n", report.ScannedHosts, report.TotalHosts)
+		if report.Incomplete {
+			fmt.Fprintf(w, "   ⚠️  Scan interrupted - report contains partial results\n")
+		}
+	}
+	fmt.Fprintf(w, strings.Repeat("=", 80)+"\n\n")
+}
@@ -0,0 +1,41 @@
+package network
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCIDR(t *testing.T) {
+	tests := []struct {
+		cidr    string
+		count   int
+		first   string
+		last    string
+		wantErr string
+	}{
+		{cidr: "192.168.1.0/30", count: 2, first: "192.168.1.1", last: "192.168.1.2"},
+		{cidr: "10.0.0.7/32", count: 1, first: "10.0.0.7", last: "10.0.0.7"},
+		{cidr: "2001:db8::/126", count: 4, first: "2001:db8::", last: "2001:db8::3"},
+		{cidr: "2001:db8::/112", count: 65536, first: "2001:db8::", last: "2001:db8::ffff"},
+		{cidr: "2001:db8::/64", wantErr: "too many to enumerate"},
+		{cidr: "192.168.1.0", wantErr: "invalid CIDR"},
+	}
+
+	for _, tt := range tests {
+		ips, err := ParseCIDR(tt.cidr)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseCIDR(%q) error = %v, want %q", tt.cidr, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseCIDR(%q) error = %v", tt.cidr, err)
+			continue
+		}
+		if len(ips) != tt.count || ips[0] != tt.first || ips[len(ips)-1] != tt.last {
+			t.Errorf("ParseCIDR(%q) = %d IPs from %s to %s, want %d from %s to %s",
+				tt.cidr, len(ips), ips[0], ips[len(ips)-1], tt.count, tt.first, tt.last)
+		}
+	}
+}
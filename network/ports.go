@@ -0,0 +1,236 @@
+package network
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PortSpec holds the ports to scan for each transport protocol
+type PortSpec struct {
+	TCP []int
+	UDP []int
+}
+
+// uniformPorts returns a PortSpec scanning the same ports over every protocol
+func uniformPorts(ports []int) PortSpec {
+	return PortSpec{TCP: ports, UDP: ports}
+}
+
+// For returns the ports to scan with a prober of the given protocol.
+// Protocols other than UDP use the TCP ports.
+func (s PortSpec) For(protocol string) []int {
+	if protocol == ProtocolUDP {
+		return s.UDP
+	}
+	return s.TCP
+}
+
+// All returns the union of the TCP and UDP ports, sorted
+func (s PortSpec) All() []int {
+	set := make(map[int]bool, len(s.TCP)+len(s.UDP))
+	for _, port := range append(append([]int{}, s.TCP...), s.UDP...) {
+		set[port] = true
+	}
+	return sortedPorts(set)
+}
+
+// Count returns how many ports the probers scan in total
+func (s PortSpec) Count(probers []Prober) int {
+	count := 0
+	for _, prober := range probers {
+		count += len(s.For(prober.Protocol()))
+	}
+	return count
+}
+
+// wellKnownPorts is the last port of the IANA well-known range
+const wellKnownPorts = 1023
+
+// namedPortSets lists the port sets accepted by ParsePortSpec by name,
+// besides top<N>
+var namedPortSets = map[string]func(protocol string) []int{
+	"all":        func(string) []int { return commonPorts() }, // Historical name of the common ports
+	"common":     func(string) []int { return commonPorts() },
+	"well-known": func(string) []int { return portRange(1, wellKnownPorts) },
+}
+
+// namedPortSetNames returns the names of namedPortSets, sorted
+func namedPortSetNames() string {
+	var names []string
+	for name := range namedPortSets {
+		names = append(names, name)
+	}
+	names = append(names, "top<N>")
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// ParsePortSpec parses a port specification. It is a comma separated list
+// of entries, each of them:
+//
+//   - a port (80) or a range (8000-8100; "1024-" and "-1024" are open
+//     ended, "-" is every port)
+//   - a named set: common (alias all), well-known, or top<N> for the N
+//     ports most likely to be open (top100, top1000, see TopPorts)
+//   - a protocol prefix, T: or U:, that applies to the entry and the ones
+//     after it (T:22,80,U:53,161); entries without a prefix apply to both
+//   - an exclusion, "!" followed by a port, range or set (1-1024,!25),
+//     applied after every inclusion for the current protocols
+//
+// An empty specification selects the common ports. Malformed entries and
+// specifications that select no port are reported as errors.
+func ParsePortSpec(spec string) (PortSpec, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		spec = "common"
+	}
+
+	include := map[string]map[int]bool{ProtocolTCP: {}, ProtocolUDP: {}}
+	exclude := map[string]map[int]bool{ProtocolTCP: {}, ProtocolUDP: {}}
+	protocols := []string{ProtocolTCP, ProtocolUDP}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		excluded := strings.HasPrefix(entry, "!")
+		if excluded {
+			entry = strings.TrimSpace(entry[1:])
+		}
+
+		if len(entry) >= 2 && entry[1] == ':' {
+			switch strings.ToUpper(entry[:1]) {
+			case "T":
+				protocols = []string{ProtocolTCP}
+			case "U":
+				protocols = []string{ProtocolUDP}
+			default:
+				return PortSpec{}, fmt.Errorf("invalid port specification %q: unknown protocol prefix %q (use T: or U:)", spec, entry[:2])
+			}
+			entry = strings.TrimSpace(entry[2:])
+		}
+		if entry == "" {
+			return PortSpec{}, fmt.Errorf("invalid port specification %q: empty entry", spec)
+		}
+
+		for _, protocol := range protocols {
+			ports, err := parsePortEntry(entry, protocol)
+			if err != nil {
+				return PortSpec{}, fmt.Errorf("invalid port specification %q: %v", spec, err)
+			}
+			target := include[protocol]
+			if excluded {
+				target = exclude[protocol]
+			}
+			for _, port := range ports {
+				target[port] = true
+			}
+		}
+	}
+
+	for protocol, ports := range exclude {
+		for port := range ports {
+			delete(include[protocol], port)
+		}
+	}
+
+	result := PortSpec{TCP: sortedPorts(include[ProtocolTCP]), UDP: sortedPorts(include[ProtocolUDP])}
+	if len(result.TCP) == 0 && len(result.UDP) == 0 {
+		return PortSpec{}, fmt.Errorf("port specification %q selects no ports", spec)
+	}
+	return result, nil
+}
+
+// parsePortEntry parses a single port, range or named set
+func parsePortEntry(entry, protocol string) ([]int, error) {
+	name := strings.ToLower(entry)
+	if set, exists := namedPortSets[name]; exists {
+		return set(protocol), nil
+	}
+	if count, isTop := strings.CutPrefix(name, "top"); isTop {
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid named set %q: top<N> needs N between 1 and 65535", entry)
+		}
+		return TopPorts(protocol, n), nil
+	}
+
+	from, to, isRange := strings.Cut(entry, "-")
+	if !isRange {
+		port, err := parsePortNumber(entry)
+		if err != nil {
+			return nil, err
+		}
+		return []int{port}, nil
+	}
+
+	start, end := 1, 65535
+	var err error
+	if from = strings.TrimSpace(from); from != "" {
+		if start, err = parsePortNumber(from); err != nil {
+			return nil, err
+		}
+	}
+	if to = strings.TrimSpace(to); to != "" {
+		if end, err = parsePortNumber(to); err != nil {
+			return nil, err
+		}
+	}
+	if start > end {
+		return nil, fmt.Errorf("range %q ends before it starts", entry)
+	}
+	return portRange(start, end), nil
+}
+
+// parsePortNumber parses a port between 1 and 65535
+func parsePortNumber(text string) (int, error) {
+	port, err := strconv.Atoi(text)
+	if err != nil {
+		if strings.Trim(text, "0123456789") == "" {
+			return 0, fmt.Errorf("port %s out of range (1-65535)", text)
+		}
+		return 0, fmt.Errorf("%q is not a port, range or named set (%s)", text, namedPortSetNames())
+	}
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("port %d out of range (1-65535)", port)
+	}
+	return port, nil
+}
+
+// portRange returns the ports from start to end
+func portRange(start, end int) []int {
+	ports := make([]int, 0, end-start+1)
+	for port := start; port <= end; port++ {
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// sortedPorts returns the ports of a set in ascending order
+func sortedPorts(set map[int]bool) []int {
+	ports := make([]int, 0, len(set))
+	for port := range set {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// commonPorts returns the ports of the common services plus a few extra
+// web and search ports
+func commonPorts() []int {
+	ports := append([]int{}, commonServicePorts...)
+	ports = append(ports, 8000, 8008, 8888, 9090, 9200, 9300)
+	sort.Ints(ports)
+	return ports
+}
+
+// ParsePortRange converts a port specification (see ParsePortSpec) to the
+// list of ports it selects for any protocol
+func ParsePortRange(portRange string) ([]int, error) {
+	spec, err := ParsePortSpec(portRange)
+	if err != nil {
+		return nil, err
+	}
+	return spec.All(), nil
+}
@@ -0,0 +1,121 @@
+package network
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParsePortSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		tcp     string
+		udp     string
+		wantErr string
+	}{
+		{spec: "80", tcp: "[80]", udp: "[80]"},
+		{spec: "22,80,8000-8003", tcp: "[22 80 8000 8001 8002 8003]", udp: "[22 80 8000 8001 8002 8003]"},
+		{spec: " 443 , 80,80 ", tcp: "[80 443]", udp: "[80 443]"},
+		{spec: "T:22,80,U:53,161", tcp: "[22 80]", udp: "[53 161]"},
+		{spec: "t:22,u:53", tcp: "[22]", udp: "[53]"},
+		{spec: "1-10,!2-8", tcp: "[1 9 10]", udp: "[1 9 10]"},
+		{spec: "20-25,U:53,!25", tcp: "[20 21 22 23 24 25]", udp: "[20 21 22 23 24 53]"},
+		{spec: "20-25,!T:25", tcp: "[20 21 22 23 24]", udp: "[20 21 22 23 24 25]"},
+		{spec: "65530-", tcp: "[65530 65531 65532 65533 65534 65535]", udp: "[65530 65531 65532 65533 65534 65535]"},
+		{spec: "-3", tcp: "[1 2 3]", udp: "[1 2 3]"},
+		{spec: "well-known,!2-1023", tcp: "[1]", udp: "[1]"},
+		{spec: "", wantErr: ""},
+		{spec: "80,,443", wantErr: "empty entry"},
+		{spec: "0", wantErr: "out of range"},
+		{spec: "70000", wantErr: "out of range"},
+		{spec: "99999999999999999999", wantErr: "out of range"},
+		{spec: "100-90", wantErr: "ends before it starts"},
+		{spec: "http", wantErr: `"http" is not a port, range or named set`},
+		{spec: "top0", wantErr: "top<N> needs N between 1 and 65535"},
+		{spec: "topx", wantErr: "invalid named set"},
+		{spec: "X:80", wantErr: "unknown protocol prefix"},
+		{spec: "80,!80", wantErr: "selects no ports"},
+		{spec: "1-2-3", wantErr: "not a port"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePortSpec(tt.spec)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParsePortSpec(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePortSpec(%q) error = %v", tt.spec, err)
+			continue
+		}
+		if tt.tcp == "" && tt.udp == "" {
+			continue // Only checks that parsing succeeds
+		}
+		if fmt.Sprint(got.TCP) != tt.tcp || fmt.Sprint(got.UDP) != tt.udp {
+			t.Errorf("ParsePortSpec(%q) = TCP %v UDP %v, want TCP %s UDP %s", tt.spec, got.TCP, got.UDP, tt.tcp, tt.udp)
+		}
+	}
+}
+
+func TestParsePortSpecNamedSets(t *testing.T) {
+	tests := []struct {
+		spec     string
+		tcp, udp int
+		contains []int
+	}{
+		{spec: "common", tcp: len(commonPorts()), udp: len(commonPorts()), contains: []int{22, 80, 443, 8000}},
+		{spec: "all", tcp: len(commonPorts()), udp: len(commonPorts())},
+		{spec: "top100", tcp: 100, udp: 100, contains: []int{80}},
+		{spec: "top1000", tcp: 1000, udp: 1000, contains: []int{49157}},
+		{spec: "TOP5", tcp: 5, udp: 5, contains: []int{21, 22, 23, 80, 443}},
+		{spec: "well-known", tcp: 1023, udp: 1023, contains: []int{1, 1023}},
+		{spec: "-", tcp: 65535, udp: 65535},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePortSpec(tt.spec)
+		if err != nil {
+			t.Errorf("ParsePortSpec(%q) error = %v", tt.spec, err)
+			continue
+		}
+		if len(got.TCP) != tt.tcp || len(got.UDP) != tt.udp {
+			t.Errorf("ParsePortSpec(%q) = %d TCP and %d UDP ports, want %d and %d", tt.spec, len(got.TCP), len(got.UDP), tt.tcp, tt.udp)
+		}
+		for _, port := range tt.contains {
+			if !containsPort(got.TCP, port) {
+				t.Errorf("ParsePortSpec(%q) TCP ports miss %d", tt.spec, port)
+			}
+		}
+	}
+
+	// top100 differs per protocol
+	top, _ := ParsePortSpec("top100")
+	if containsPort(top.TCP, 161) || !containsPort(top.UDP, 161) {
+		t.Errorf("top100: SNMP (161) should be a top UDP port only")
+	}
+}
+
+func TestParsePortRange(t *testing.T) {
+	ports, err := ParsePortRange("T:22,U:53,22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ports) != "[22 53]" {
+		t.Errorf("ParsePortRange = %v, want [22 53]", ports)
+	}
+	if _, err := ParsePortRange("abc"); err == nil {
+		t.Error("ParsePortRange(\"abc\") succeeded, want an error")
+	}
+}
+
+// containsPort reports whether ports contains port
+func containsPort(ports []int, port int) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ConnectProber classifies TCP ports with a full connect (nmap -sT)
+type ConnectProber struct {
+	Timeout time.Duration
+}
+
+// Protocol returns "tcp"
+func (p ConnectProber) Protocol() string { return "tcp" }
+
+// withTimeout returns the prober with another timeout
+func (p ConnectProber) withTimeout(timeout time.Duration) Prober {
+	p.Timeout = timeout
+	return p
+}
+
+// Probe connects to the port and closes the connection right away
+func (p ConnectProber) Probe(ctx context.Context, ip string, port int) PortResult {
+	result, conn := dialTCP(ctx, ip, port, p.Timeout)
+	if conn != nil {
+		conn.Close()
+	}
+	return result
+}
+
+// BannerProber connects like ConnectProber and then reads the banner sent
+// by the service to identify it and extract its version
+type BannerProber struct {
+	Timeout time.Duration
+}
+
+// Protocol returns "tcp"
+func (p BannerProber) Protocol() string { return "tcp" }
+
+// Probe connects to the port and grabs the service banner when open
+func (p BannerProber) Probe(ctx context.Context, ip string, port int) PortResult {
+	result, conn := dialTCP(ctx, ip, port, p.Timeout)
+	if conn == nil {
+		return result
+	}
+	defer conn.Close()
+
+	// Unblock the banner read if the scan is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	conn.SetReadDeadline(time.Now().Add(p.Timeout))
+	buffer := make([]byte, 2048)
+	n, err := conn.Read(buffer)
+	if err == nil && n > 0 {
+		result.Banner = strings.TrimSpace(string(buffer[:n]))
+		// Extract version from banner
+		result.Version = extractVersionFromBanner(result.Banner)
+		// Identify service by banner
+		result.Service = identifyServiceByBanner(result.Banner, result.Service)
+	}
+
+	return result
+}
+
+// dialTCP connects to ip:port and classifies the outcome. The connection is
+// returned only when the port is open; the caller must close it.
+func dialTCP(ctx context.Context, ip string, port int, timeout time.Duration) (PortResult, net.Conn) {
+	result := PortResult{
+		IP:       ip,
+		Port:     port,
+		Protocol: "tcp",
+		State:    StateFiltered,
+		Service:  ServiceName("tcp", port),
+		Reason:   "no-response",
+	}
+
+	start := time.Now()
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, strconv.Itoa(port)))
+	result.ResponseTime = time.Since(start)
+
+	if err != nil {
+		result.State, result.Reason = classifyDialError(err)
+		return result, nil
+	}
+
+	result.IsOpen = true
+	result.State = StateOpen
+	result.Reason = "syn-ack"
+	return result, conn
+}
+
+// classifyDialError maps a failed connect to a port state and nmap reason
+func classifyDialError(err error) (state, reason string) {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED), strings.Contains(err.Error(), "refused"):
+		// The target answered with RST
+		return StateClosed, "conn-refused"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return StateFiltered, "no-response"
+	case errors.Is(err, syscall.EHOSTUNREACH):
+		return StateFiltered, "host-unreach"
+	case errors.Is(err, syscall.ENETUNREACH):
+		return StateFiltered, "net-unreach"
+	case errors.Is(err, syscall.EACCES), errors.Is(err, syscall.EPERM):
+		// A local firewall rejected the packet
+		return StateFiltered, "admin-prohibited"
+	default:
+		return StateFiltered, "no-response"
+	}
+}
@@ -0,0 +1,144 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// StateOpenFiltered is reported for UDP ports that neither answered nor
+// returned an ICMP port-unreachable, and for unanswered FIN, NULL and Xmas
+// probes: the probe may have been dropped by a firewall or ignored by the
+// service
+const StateOpenFiltered = "open|filtered"
+
+// UDPProber classifies UDP ports (nmap -sU). A reply means open, an ICMP
+// port-unreachable means closed, and silence means open|filtered.
+type UDPProber struct {
+	Timeout time.Duration
+	Retries int // Extra probes sent when there is no answer
+}
+
+// Protocol returns "udp"
+func (p UDPProber) Protocol() string { return "udp" }
+
+// withTimeout returns the prober with another timeout
+func (p UDPProber) withTimeout(timeout time.Duration) Prober {
+	p.Timeout = timeout
+	return p
+}
+
+// Probe sends the protocol payload for the port and waits for a reply
+func (p UDPProber) Probe(ctx context.Context, ip string, port int) PortResult {
+	result := PortResult{
+		IP:       ip,
+		Port:     port,
+		Protocol: "udp",
+		State:    StateOpenFiltered,
+		Service:  ServiceName("udp", port),
+		Reason:   "no-response",
+	}
+
+	// A connected socket receives the ICMP errors for this destination
+	start := time.Now()
+	dialer := net.Dialer{Timeout: p.Timeout}
+	conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(ip, strconv.Itoa(port)))
+	if err != nil {
+		result.State, result.Reason = classifyUDPError(err)
+		result.ResponseTime = time.Since(start)
+		return result
+	}
+	defer conn.Close()
+
+	// Unblock the read if the scan is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	// Protocol-specific payload from the probe database, else an empty datagram
+	var probe *serviceProbe
+	var payload []byte
+	if probes := probesFor(ProtocolUDP, port); len(probes) > 0 {
+		probe, payload = probes[0], probes[0].payload
+	}
+	buffer := make([]byte, 2048)
+
+	for attempt := 0; attempt <= p.Retries; attempt++ {
+		if _, err := conn.Write(payload); err != nil {
+			result.State, result.Reason = classifyUDPError(err)
+			break
+		}
+
+		conn.SetReadDeadline(time.Now().Add(p.Timeout))
+		n, err := conn.Read(buffer)
+		if err == nil {
+			result.IsOpen = true
+			result.State = StateOpen
+			result.Reason = "udp-response"
+			result.Banner = printableBanner(buffer[:n])
+			if info, ok := probe.match(buffer[:n]); ok {
+				applyServiceInfo(&result, info)
+			}
+			if result.Product == "" {
+				result.Version = extractVersionFromBanner(result.Banner)
+			}
+			break
+		}
+
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			continue
+		}
+		result.State, result.Reason = classifyUDPError(err)
+		break
+	}
+
+	result.ResponseTime = time.Since(start)
+	return result
+}
+
+// classifyUDPError maps a socket error of a UDP probe to a port state
+func classifyUDPError(err error) (state, reason string) {
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET),
+		strings.Contains(err.Error(), "refused"):
+		// ICMP port unreachable (reported as a reset on Windows)
+		return StateClosed, "port-unreach"
+	case errors.Is(err, syscall.EHOSTUNREACH):
+		return StateFiltered, "host-unreach"
+	case errors.Is(err, syscall.ENETUNREACH):
+		return StateFiltered, "net-unreach"
+	case errors.Is(err, syscall.EACCES), errors.Is(err, syscall.EPERM):
+		return StateFiltered, "admin-prohibited"
+	default:
+		return StateOpenFiltered, "no-response"
+	}
+}
+
+// printableBanner returns the reply as text when at least 80% of its bytes
+// are printable ASCII, so binary protocol answers do not end up garbling the
+// reports. Remaining non-printable bytes are replaced with dots.
+func printableBanner(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	printable := 0
+	text := make([]byte, len(data))
+	for i, b := range data {
+		if (b >= 0x20 && b <= 0x7e) || b == '\n' || b == '\r' || b == '\t' {
+			printable++
+			text[i] = b
+		} else {
+			text[i] = '.'
+		}
+	}
+
+	if printable*10 < len(data)*8 {
+		return ""
+	}
+	return strings.TrimSpace(string(text))
+}
@@ -0,0 +1,126 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// listenUDP opens a UDP socket on a free loopback port
+func listenUDP(t *testing.T) *net.UDPConn {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestUDPProberOpen(t *testing.T) {
+	conn := listenUDP(t)
+	go func() {
+		buffer := make([]byte, 2048)
+		for {
+			_, addr, err := conn.ReadFromUDP(buffer)
+			if err != nil {
+				return
+			}
+			conn.WriteToUDP([]byte("echo-service 1.0\n"), addr)
+		}
+	}()
+
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	result := UDPProber{Timeout: time.Second}.Probe(context.Background(), "127.0.0.1", port)
+
+	if result.State != StateOpen || !result.IsOpen || result.Reason != "udp-response" {
+		t.Fatalf("got state %q open=%v reason %q, want open udp-response", result.State, result.IsOpen, result.Reason)
+	}
+	if result.Protocol != "udp" {
+		t.Errorf("protocol = %q, want udp", result.Protocol)
+	}
+	if result.Version != "echo-service 1.0" {
+		t.Errorf("version = %q, want %q", result.Version, "echo-service 1.0")
+	}
+}
+
+func TestUDPProberClosed(t *testing.T) {
+	// A port that was just released answers with ICMP port-unreachable
+	conn := listenUDP(t)
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	conn.Close()
+
+	result := UDPProber{Timeout: time.Second}.Probe(context.Background(), "127.0.0.1", port)
+
+	if result.State != StateClosed || result.IsOpen || result.Reason != "port-unreach" {
+		t.Fatalf("got state %q open=%v reason %q, want closed port-unreach", result.State, result.IsOpen, result.Reason)
+	}
+}
+
+func TestUDPProberOpenFiltered(t *testing.T) {
+	// A bound socket that never answers looks like a dropped probe
+	conn := listenUDP(t)
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	result := UDPProber{Timeout: 100 * time.Millisecond, Retries: 1}.Probe(context.Background(), "127.0.0.1", port)
+
+	if result.State != StateOpenFiltered || result.IsOpen || result.Reason != "no-response" {
+		t.Fatalf("got state %q open=%v reason %q, want open|filtered no-response", result.State, result.IsOpen, result.Reason)
+	}
+	if result.ResponseTime < 200*time.Millisecond {
+		t.Errorf("response time %v, want both attempts to wait for the timeout", result.ResponseTime)
+	}
+}
+
+func TestClassifyUDPError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		state  string
+		reason string
+	}{
+		{"refused", &net.OpError{Op: "read", Net: "udp", Err: os.NewSyscallError("recvfrom", syscall.ECONNREFUSED)}, StateClosed, "port-unreach"},
+		{"reset", syscall.ECONNRESET, StateClosed, "port-unreach"},
+		{"refused text", errors.New("wsarecv: connection refused"), StateClosed, "port-unreach"},
+		{"host unreachable", fmt.Errorf("write: %w", syscall.EHOSTUNREACH), StateFiltered, "host-unreach"},
+		{"network unreachable", syscall.ENETUNREACH, StateFiltered, "net-unreach"},
+		{"prohibited", syscall.EACCES, StateFiltered, "admin-prohibited"},
+		{"other", errors.New("i/o timeout"), StateOpenFiltered, "no-response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, reason := classifyUDPError(tt.err)
+			if state != tt.state || reason != tt.reason {
+				t.Errorf("classifyUDPError(%v) = %q, %q; want %q, %q", tt.err, state, reason, tt.state, tt.reason)
+			}
+		})
+	}
+}
+
+func TestPrintableBanner(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, ""},
+		{"text", []byte("SSH-2.0-OpenSSH_9.0\r\n"), "SSH-2.0-OpenSSH_9.0"},
+		{"mostly text", []byte("STAT pid 42\x00\r\n"), "STAT pid 42."},
+		{"binary", []byte("\x81\x80\xff\xfe hello binary"), ""},
+		{"invalid utf-8", []byte("\xff\xfe\xfd\xfc\xfb"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := printableBanner(tt.data); got != tt.want {
+				t.Errorf("printableBanner(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
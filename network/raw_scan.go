@@ -0,0 +1,240 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// ErrRawScanUnsupported is returned by the raw TCP scans on systems where
+// they are not implemented
+var ErrRawScanUnsupported = errors.New("raw TCP scans are not supported on this system")
+
+// TCP scan techniques accepted by StealthyScanConfig.ScanType. Every
+// technique but connect crafts packets on a raw socket and needs
+// CAP_NET_RAW.
+const (
+	ScanTypeConnect = "connect" // Full TCP connect (nmap -sT), needs no privileges
+	ScanTypeSYN     = "syn"     // Half-open SYN scan (nmap -sS)
+	ScanTypeFIN     = "fin"     // FIN scan (nmap -sF)
+	ScanTypeNULL    = "null"    // No flags (nmap -sN)
+	ScanTypeXmas    = "xmas"    // FIN, PSH and URG (nmap -sX)
+	ScanTypeACK     = "ack"     // ACK scan, maps firewall rules (nmap -sA)
+	ScanTypeWindow  = "window"  // ACK scan reading the RST window (nmap -sW)
+)
+
+// StateUnfiltered is reported by ACK scans for ports that answered with a
+// RST: the port is reachable through the firewall, open or closed
+const StateUnfiltered = "unfiltered"
+
+// rawScanFlags are the TCP flags each raw technique sends
+var rawScanFlags = map[string]uint8{
+	ScanTypeSYN:    tcpSYN,
+	ScanTypeFIN:    tcpFIN,
+	ScanTypeNULL:   0,
+	ScanTypeXmas:   tcpFIN | tcpPSH | tcpURG,
+	ScanTypeACK:    tcpACK,
+	ScanTypeWindow: tcpACK,
+}
+
+// ParseScanType normalizes a scan technique ("" means connect)
+func ParseScanType(name string) (string, error) {
+	scanType := strings.ToLower(strings.TrimSpace(name))
+	if scanType == "" {
+		return ScanTypeConnect, nil
+	}
+	if _, raw := rawScanFlags[scanType]; raw || scanType == ScanTypeConnect {
+		return scanType, nil
+	}
+	return "", fmt.Errorf("unknown scan type %q (supported: connect, syn, fin, null, xmas, ack, window)", name)
+}
+
+// scanTypeLabel describes a scan technique in the text reports
+func scanTypeLabel(scanType string) string {
+	switch scanType {
+	case ScanTypeConnect:
+		return "TCP connect (-sT)"
+	case ScanTypeSYN:
+		return "TCP SYN, half-open (-sS)"
+	case ScanTypeFIN:
+		return "TCP FIN (-sF)"
+	case ScanTypeNULL:
+		return "TCP NULL (-sN)"
+	case ScanTypeXmas:
+		return "TCP Xmas (-sX)"
+	case ScanTypeACK:
+		return "TCP ACK, firewall rules (-sA)"
+	case ScanTypeWindow:
+		return "TCP Window (-sW)"
+	}
+	return scanType
+}
+
+// rawReply is a TCP segment answering a raw probe
+type rawReply struct {
+	segment tcpSegment
+	ttl     int
+}
+
+// answers reports whether segment is a RST or SYN-ACK answering probe. A
+// SYN must be acknowledged, which also tells the probe apart from its own
+// copy on the loopback interface.
+func answers(probe, segment tcpSegment) bool {
+	synAck := segment.flags&(tcpSYN|tcpACK) == tcpSYN|tcpACK
+	if segment.flags&tcpRST == 0 && !synAck {
+		return false
+	}
+	if probe.flags&tcpSYN != 0 {
+		return segment.flags&tcpACK != 0 && segment.ack == probe.seq+1
+	}
+	return true
+}
+
+// RawProber classifies TCP ports with probes crafted on a raw socket, one
+// technique per prober (ScanTypeSYN, ScanTypeFIN, ...). SYN scans never
+// complete the handshake: a SYN-ACK means open and is torn down by the
+// kernel's RST, since no local socket owns the connection. Create it with
+// NewRawProber and Close it once the scan is over.
+type RawProber struct {
+	ScanType string
+	Timeout  time.Duration
+	Retries  int // Retransmissions of unanswered probes
+	engine   *rawTCPEngine
+}
+
+// NewRawProber opens the raw socket used to probe the address family of
+// target with a raw technique. It fails without CAP_NET_RAW and on systems
+// other than Linux.
+func NewRawProber(target, scanType string, timeout time.Duration) (*RawProber, error) {
+	addr, err := netip.ParseAddr(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP: %s", target)
+	}
+	if _, raw := rawScanFlags[scanType]; !raw {
+		return nil, fmt.Errorf("%q is not a raw scan type", scanType)
+	}
+	engine, err := openRawTCP(addr.Unmap().Is6())
+	if err != nil {
+		return nil, err
+	}
+	return &RawProber{ScanType: scanType, Timeout: timeout, Retries: 1, engine: engine}, nil
+}
+
+// Close releases the raw socket
+func (p *RawProber) Close() error { return p.engine.close() }
+
+// Protocol returns "tcp"
+func (p *RawProber) Protocol() string { return "tcp" }
+
+// withTimeout returns a prober sharing the raw socket with another timeout
+func (p *RawProber) withTimeout(timeout time.Duration) Prober {
+	tuned := *p
+	tuned.Timeout = timeout
+	return &tuned
+}
+
+// Probe sends the technique's probe to the port and classifies the answer
+// the way nmap does
+func (p *RawProber) Probe(ctx context.Context, ip string, port int) PortResult {
+	result := PortResult{
+		IP:       ip,
+		Port:     port,
+		Protocol: "tcp",
+		State:    StateFiltered,
+		Service:  ServiceName("tcp", port),
+		Reason:   "no-response",
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		result.Reason = "error"
+		return result
+	}
+
+	start := time.Now()
+	reply, err := p.exchange(ctx, addr.Unmap(), port, rawScanFlags[p.ScanType])
+	result.ResponseTime = time.Since(start)
+	if err != nil {
+		result.State, result.Reason = classifyDialError(err)
+		return result
+	}
+	if reply == nil {
+		// Stacks following RFC 793 drop FIN, NULL and Xmas probes to open
+		// ports; a firewall drops any probe
+		switch p.ScanType {
+		case ScanTypeFIN, ScanTypeNULL, ScanTypeXmas:
+			result.State = StateOpenFiltered
+		}
+		return result
+	}
+
+	result.TTL = reply.ttl
+	if reply.segment.flags&tcpRST == 0 {
+		// Only a SYN is answered with a SYN-ACK
+		result.IsOpen = true
+		result.State, result.Reason = StateOpen, "syn-ack"
+		result.Window = int(reply.segment.window)
+		return result
+	}
+
+	result.State, result.Reason = StateClosed, "reset"
+	switch p.ScanType {
+	case ScanTypeACK:
+		result.State = StateUnfiltered
+	case ScanTypeWindow:
+		// Some stacks advertise a window in the RST of open ports only
+		if reply.segment.window > 0 {
+			result.IsOpen = true
+			result.State = StateOpen
+		}
+		result.Window = int(reply.segment.window)
+	}
+	return result
+}
+
+// exchange sends a probe with flags, retransmitting it while unanswered.
+// It returns nil when every attempt timed out.
+func (p *RawProber) exchange(ctx context.Context, addr netip.Addr, port int, flags uint8) (*rawReply, error) {
+	for attempt := 0; attempt <= p.Retries; attempt++ {
+		reply, err := p.engine.exchange(ctx, addr, port, flags, p.Timeout)
+		if err != nil || reply != nil {
+			return reply, err
+		}
+	}
+	return nil, nil
+}
+
+// serviceAfterRaw runs service detection on the ports a raw scan found
+// open, like nmap -sS -sV: the probes need a full connection
+type serviceAfterRaw struct {
+	raw     *RawProber
+	service ServiceProber
+}
+
+// Protocol returns "tcp"
+func (p serviceAfterRaw) Protocol() string { return "tcp" }
+
+// withTimeout tunes the raw probe only; service detection keeps its timeout
+func (p serviceAfterRaw) withTimeout(timeout time.Duration) Prober {
+	p.raw = p.raw.withTimeout(timeout).(*RawProber)
+	return p
+}
+
+// Probe classifies the port with a raw probe, then identifies open services
+func (p serviceAfterRaw) Probe(ctx context.Context, ip string, port int) PortResult {
+	result := p.raw.Probe(ctx, ip, port)
+	if !result.IsOpen {
+		return result
+	}
+	identified := p.service.Probe(ctx, ip, port)
+	if !identified.IsOpen {
+		// The port closed in between; keep what the raw scan saw
+		return result
+	}
+	identified.Reason, identified.ResponseTime = result.Reason, result.ResponseTime
+	identified.TTL, identified.Window = result.TTL, result.Window
+	return identified
+}
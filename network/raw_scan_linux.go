@@ -0,0 +1,253 @@
+//go:build linux
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// rawKey identifies the answers to one raw probe
+type rawKey struct {
+	remote     netip.Addr
+	remotePort uint16
+	localPort  uint16
+}
+
+// rawTCPEngine sends crafted TCP segments on a raw socket and hands the
+// segments received back to the probes waiting for them. Raw TCP sockets
+// receive a copy of every incoming TCP segment of their family; a single
+// reader goroutine demultiplexes them by address and ports.
+type rawTCPEngine struct {
+	fd   int
+	ipv6 bool
+
+	mu        sync.Mutex
+	waiters   map[rawKey]chan rawReply
+	sources   map[netip.Addr]netip.Addr // Local address routed to each target
+	localPort uint16                    // Last source port handed out
+
+	done chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// openRawTCP opens a raw TCP socket (CAP_NET_RAW) and starts its reader
+func openRawTCP(ipv6 bool) (*rawTCPEngine, error) {
+	family := syscall.AF_INET
+	if ipv6 {
+		family = syscall.AF_INET6
+	}
+	fd, err := syscall.Socket(family, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.IPPROTO_TCP)
+	if err != nil {
+		return nil, fmt.Errorf("opening raw TCP socket (needs CAP_NET_RAW): %v", err)
+	}
+
+	if ipv6 {
+		// Let the kernel fill the checksum at offset 16, with the source
+		// address it picks, and report the hop limit of the answers
+		err = syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_CHECKSUM, 16)
+		if err == nil {
+			err = syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_RECVHOPLIMIT, 1)
+		}
+	}
+	if err == nil {
+		tv := syscall.NsecToTimeval(icmpPollInterval.Nanoseconds())
+		err = syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv)
+	}
+	if err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("configuring raw TCP socket: %v", err)
+	}
+
+	engine := &rawTCPEngine{
+		fd:        fd,
+		ipv6:      ipv6,
+		waiters:   make(map[rawKey]chan rawReply),
+		sources:   make(map[netip.Addr]netip.Addr),
+		localPort: uint16(32768 + rand.Intn(28000)),
+		done:      make(chan struct{}),
+	}
+	engine.wg.Add(1)
+	go engine.read()
+	return engine, nil
+}
+
+// close stops the reader and releases the socket
+func (e *rawTCPEngine) close() error {
+	e.once.Do(func() {
+		close(e.done)
+		// The reader notices within one poll interval; closing the socket
+		// under it could hand its descriptor to another file
+		e.wg.Wait()
+		syscall.Close(e.fd)
+	})
+	return nil
+}
+
+// read delivers incoming segments to the probes waiting for them
+func (e *rawTCPEngine) read() {
+	defer e.wg.Done()
+	buffer := make([]byte, 1500)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	for {
+		select {
+		case <-e.done:
+			return
+		default:
+		}
+
+		n, oobn, _, from, err := syscall.Recvmsg(e.fd, buffer, oob, 0)
+		if err == syscall.EAGAIN || err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return
+		}
+
+		var remote netip.Addr
+		var ttl int
+		data := buffer[:n]
+		if e.ipv6 {
+			sa, ok := from.(*syscall.SockaddrInet6)
+			if !ok {
+				continue
+			}
+			remote, ttl = netip.AddrFrom16(sa.Addr), receivedTTL(oob[:oobn])
+		} else {
+			// Raw IPv4 sockets return the IP header too
+			headerLen := int(data[0]&0x0f) * 4
+			if len(data) < headerLen+20 || headerLen < 20 {
+				continue
+			}
+			remote, ttl = netip.AddrFrom4([4]byte(data[12:16])), int(data[8])
+			data = data[headerLen:]
+		}
+
+		segment, err := parseTCPSegment(data)
+		if err != nil {
+			continue
+		}
+		e.mu.Lock()
+		waiter := e.waiters[rawKey{remote, segment.srcPort, segment.dstPort}]
+		e.mu.Unlock()
+		if waiter != nil {
+			select {
+			case waiter <- rawReply{segment: segment, ttl: ttl}:
+			default:
+			}
+		}
+	}
+}
+
+// source returns the local address the kernel routes addr from
+func (e *rawTCPEngine) source(addr netip.Addr) (netip.Addr, error) {
+	e.mu.Lock()
+	src, ok := e.sources[addr]
+	e.mu.Unlock()
+	if ok {
+		return src, nil
+	}
+
+	// Connecting a UDP socket only looks the route up
+	conn, err := net.Dial("udp", netip.AddrPortFrom(addr, 9).String())
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer conn.Close()
+	src = conn.LocalAddr().(*net.UDPAddr).AddrPort().Addr().Unmap().WithZone("")
+
+	e.mu.Lock()
+	e.sources[addr] = src
+	e.mu.Unlock()
+	return src, nil
+}
+
+// register reserves a source port for a probe to addr:port
+func (e *rawTCPEngine) register(addr netip.Addr, port uint16) (rawKey, chan rawReply) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for {
+		e.localPort++
+		if e.localPort < 32768 {
+			e.localPort = 32768
+		}
+		key := rawKey{addr, port, e.localPort}
+		if _, busy := e.waiters[key]; !busy {
+			waiter := make(chan rawReply, 1)
+			e.waiters[key] = waiter
+			return key, waiter
+		}
+	}
+}
+
+// exchange sends one probe with flags to addr:port and waits up to timeout
+// for the RST or SYN-ACK answering it. It returns nil on timeout.
+func (e *rawTCPEngine) exchange(ctx context.Context, addr netip.Addr, port int, flags uint8, timeout time.Duration) (*rawReply, error) {
+	if addr.Is6() != e.ipv6 {
+		return nil, fmt.Errorf("raw TCP socket does not match the address family of %s", addr)
+	}
+	src, err := e.source(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	key, waiter := e.register(addr.WithZone(""), uint16(port))
+	defer func() {
+		e.mu.Lock()
+		delete(e.waiters, key)
+		e.mu.Unlock()
+	}()
+
+	probe := tcpSegment{
+		srcPort: key.localPort,
+		dstPort: key.remotePort,
+		seq:     rand.Uint32(),
+		flags:   flags,
+		window:  rawWindow,
+	}
+	if flags&tcpACK != 0 {
+		probe.ack = rand.Uint32()
+	}
+	if err := syscall.Sendto(e.fd, buildTCPSegment(src, key.remote, probe), 0, e.sockaddr(addr)); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case reply := <-waiter:
+			if answers(probe, reply.segment) {
+				return &reply, nil
+			}
+		case <-timer.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// sockaddr converts addr, with its IPv6 zone, to a socket address
+func (e *rawTCPEngine) sockaddr(addr netip.Addr) syscall.Sockaddr {
+	if !e.ipv6 {
+		return &syscall.SockaddrInet4{Addr: addr.As4()}
+	}
+	sa := &syscall.SockaddrInet6{Addr: addr.As16()}
+	if zone := addr.Zone(); zone != "" {
+		if iface, err := net.InterfaceByName(zone); err == nil {
+			sa.ZoneId = uint32(iface.Index)
+		} else if index, err := strconv.Atoi(zone); err == nil {
+			sa.ZoneId = uint32(index)
+		}
+	}
+	return sa
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package network
+
+import (
+	"context"
+	"net/netip"
+	"time"
+)
+
+// rawTCPEngine is only implemented on Linux
+type rawTCPEngine struct{}
+
+// openRawTCP is only implemented on Linux
+func openRawTCP(ipv6 bool) (*rawTCPEngine, error) {
+	return nil, ErrRawScanUnsupported
+}
+
+func (e *rawTCPEngine) close() error { return nil }
+
+func (e *rawTCPEngine) exchange(ctx context.Context, addr netip.Addr, port int, flags uint8, timeout time.Duration) (*rawReply, error) {
+	return nil, ErrRawScanUnsupported
+}
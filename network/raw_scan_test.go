@@ -0,0 +1,230 @@
+package network
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseScanType(t *testing.T) {
+	tests := map[string]string{"": ScanTypeConnect, "connect": ScanTypeConnect, " SYN ": ScanTypeSYN, "Xmas": ScanTypeXmas, "window": ScanTypeWindow}
+	for name, want := range tests {
+		if got, err := ParseScanType(name); err != nil || got != want {
+			t.Errorf("ParseScanType(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseScanType("stealth"); err == nil {
+		t.Error("ParseScanType(stealth) accepted an unknown technique")
+	}
+}
+
+func TestBuildTCPSegment(t *testing.T) {
+	for _, pair := range [][2]string{{"192.0.2.1", "198.51.100.7"}, {"2001:db8::1", "2001:db8::7"}} {
+		src, dst := netip.MustParseAddr(pair[0]), netip.MustParseAddr(pair[1])
+		probe := tcpSegment{srcPort: 40000, dstPort: 443, seq: 0x01020304, flags: tcpSYN, window: rawWindow}
+
+		packet := buildTCPSegment(src, dst, probe)
+		if len(packet) != 24 || packet[12] != 0x60 || string(packet[20:]) != "\x02\x04\x05\xb4" {
+			t.Fatalf("SYN segment %x, want a 24-byte header with the MSS option", packet)
+		}
+		if tcpChecksum(src, dst, packet) != 0 {
+			t.Errorf("%s: segment with its checksum does not sum to zero", src)
+		}
+		if got, err := parseTCPSegment(packet); err != nil || got != probe {
+			t.Errorf("parseTCPSegment = %+v, %v; want %+v", got, err, probe)
+		}
+	}
+
+	ack := buildTCPSegment(netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2"), tcpSegment{flags: tcpACK})
+	if len(ack) != 20 || ack[12] != 0x50 {
+		t.Errorf("ACK segment %x, want a bare 20-byte header", ack)
+	}
+	if _, err := parseTCPSegment(ack[:19]); err == nil {
+		t.Error("parseTCPSegment accepted a truncated header")
+	}
+}
+
+func TestAnswers(t *testing.T) {
+	syn := tcpSegment{seq: 100, flags: tcpSYN}
+	tests := []struct {
+		name    string
+		probe   tcpSegment
+		segment tcpSegment
+		want    bool
+	}{
+		{"syn-ack", syn, tcpSegment{ack: 101, flags: tcpSYN | tcpACK}, true},
+		{"rst-ack", syn, tcpSegment{ack: 101, flags: tcpRST | tcpACK}, true},
+		{"stale syn-ack", syn, tcpSegment{ack: 55, flags: tcpSYN | tcpACK}, false},
+		{"own syn on loopback", syn, tcpSegment{seq: 100, flags: tcpSYN}, false},
+		{"rst to an ack probe", tcpSegment{flags: tcpACK}, tcpSegment{flags: tcpRST}, true},
+		{"data", tcpSegment{flags: tcpACK}, tcpSegment{flags: tcpACK | tcpPSH}, false},
+	}
+	for _, tt := range tests {
+		if got := answers(tt.probe, tt.segment); got != tt.want {
+			t.Errorf("%s: answers = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// newRawProber opens a raw prober or skips the test without raw sockets
+func newRawProber(t *testing.T, target, scanType string, timeout time.Duration) *RawProber {
+	t.Helper()
+	prober, err := NewRawProber(target, scanType, timeout)
+	if err != nil {
+		t.Skipf("raw sockets unavailable (needs Linux and CAP_NET_RAW): %v", err)
+	}
+	t.Cleanup(func() { prober.Close() })
+	return prober
+}
+
+func TestSYNProberLoopback(t *testing.T) {
+	prober := newRawProber(t, "127.0.0.1", ScanTypeSYN, time.Second)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	open := listener.Addr().(*net.TCPAddr).Port
+
+	result := prober.Probe(context.Background(), "127.0.0.1", open)
+	if result.State != StateOpen || result.Reason != "syn-ack" || result.TTL != 64 || result.Window == 0 {
+		t.Errorf("open port = %+v, want open by syn-ack with TTL 64 and a window", result)
+	}
+
+	// The handshake was never completed, so there is nothing to accept
+	listener.(*net.TCPListener).SetDeadline(time.Now().Add(200 * time.Millisecond))
+	if conn, err := listener.Accept(); err == nil {
+		conn.Close()
+		t.Error("the SYN probe completed a connection")
+	}
+
+	closedListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	closed := closedListener.Addr().(*net.TCPAddr).Port
+	closedListener.Close()
+
+	result = prober.Probe(context.Background(), "127.0.0.1", closed)
+	if result.State != StateClosed || result.Reason != "reset" {
+		t.Errorf("closed port = %+v, want closed by reset", result)
+	}
+}
+
+// loopbackPorts returns a listening port and a closed one on 127.0.0.1
+func loopbackPorts(t *testing.T) (open, closed int) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	closedListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	closedListener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, closedListener.Addr().(*net.TCPAddr).Port
+}
+
+func TestRawProberScanTypes(t *testing.T) {
+	open, closed := loopbackPorts(t)
+
+	// Linux follows RFC 793: FIN, NULL and Xmas probes to open ports are
+	// dropped, anything else gets a RST with a zero window
+	tests := []struct {
+		scanType               string
+		openState, closedState string
+	}{
+		{ScanTypeFIN, StateOpenFiltered, StateClosed},
+		{ScanTypeNULL, StateOpenFiltered, StateClosed},
+		{ScanTypeXmas, StateOpenFiltered, StateClosed},
+		{ScanTypeACK, StateUnfiltered, StateUnfiltered},
+		{ScanTypeWindow, StateClosed, StateClosed},
+	}
+	for _, tt := range tests {
+		prober := newRawProber(t, "127.0.0.1", tt.scanType, 200*time.Millisecond)
+		if got := prober.Probe(context.Background(), "127.0.0.1", open); got.State != tt.openState {
+			t.Errorf("%s scan of a listening port = %s (%s), want %s", tt.scanType, got.State, got.Reason, tt.openState)
+		}
+		if got := prober.Probe(context.Background(), "127.0.0.1", closed); got.State != tt.closedState || got.Reason != "reset" {
+			t.Errorf("%s scan of a closed port = %s (%s), want %s (reset)", tt.scanType, got.State, got.Reason, tt.closedState)
+		}
+	}
+
+	if _, err := NewRawProber("127.0.0.1", ScanTypeConnect, time.Second); err == nil {
+		t.Error("NewRawProber accepted the connect technique")
+	}
+}
+
+func TestScanHostStealthyACK(t *testing.T) {
+	open, closed := loopbackPorts(t)
+	first, last := min(open, closed), max(open, closed)
+	if last-first > 100 {
+		t.Skip("listening and closed ports too far apart")
+	}
+
+	report, err := ScanHostStealthy(StealthyScanConfig{
+		TargetIP:  "127.0.0.1",
+		StartPort: first,
+		EndPort:   last,
+		Timeout:   200 * time.Millisecond,
+		Threads:   10,
+		ScanType:  ScanTypeACK,
+	})
+	if err != nil {
+		t.Fatalf("ScanHostStealthy: %v", err)
+	}
+	if report.ScanType != ScanTypeACK {
+		t.Skipf("ACK scan unavailable: %q", report.Warnings)
+	}
+	if report.UnfilteredPorts != report.TotalPorts || report.OpenPorts != 0 || report.ClosedPorts != 0 {
+		t.Errorf("ACK scan of loopback: %d unfiltered, %d open, %d closed of %d, want every port unfiltered",
+			report.UnfilteredPorts, report.OpenPorts, report.ClosedPorts, report.TotalPorts)
+	}
+}
+
+func TestScanHostStealthySYN(t *testing.T) {
+	port := serveTCP(t, func(conn net.Conn) {
+		conn.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	report, err := ScanHostStealthy(StealthyScanConfig{
+		TargetIP:         "127.0.0.1",
+		StartPort:        port,
+		EndPort:          port,
+		Timeout:          time.Second,
+		Threads:          1,
+		ServiceDetection: true,
+		ScanType:         ScanTypeSYN,
+	})
+	if err != nil {
+		t.Fatalf("ScanHostStealthy: %v", err)
+	}
+
+	// Without raw sockets the scan falls back to connect and says so
+	if report.ScanType == ScanTypeConnect {
+		if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "using connect scan") {
+			t.Errorf("fallback warnings = %q", report.Warnings)
+		}
+	} else if report.ScanType != ScanTypeSYN || len(report.Warnings) != 0 {
+		t.Errorf("scan type %q with warnings %q, want syn", report.ScanType, report.Warnings)
+	}
+
+	if report.OpenPorts != 1 {
+		t.Fatalf("open ports = %d, want 1: %+v", report.OpenPorts, report.Results)
+	}
+	result := report.Results[0]
+	if result.Product != "OpenSSH" || result.Version != "9.6" || result.Reason != "syn-ack" {
+		t.Errorf("result = %+v, want OpenSSH 9.6 identified after the SYN", result)
+	}
+	if report.ScanType == ScanTypeSYN && result.TTL != 64 {
+		t.Errorf("TTL = %d, want the SYN-ACK TTL kept after service detection", result.TTL)
+	}
+}
@@ -0,0 +1,211 @@
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OutputFormat selects how scan reports are written
+type OutputFormat string
+
+// Supported output formats
+const (
+	FormatText OutputFormat = "text" // Human-readable report (same as the Print functions)
+	FormatJSON OutputFormat = "json" // Versioned JSON document (see JSONSchemaVersion)
+	FormatXML  OutputFormat = "xml"  // nmap-compatible XML (nmap -oX)
+	FormatCSV  OutputFormat = "csv"  // One row per port
+	FormatGrep OutputFormat = "grep" // nmap-style grepable output (nmap -oG)
+)
+
+// OutputFormats returns the supported output formats
+func OutputFormats() []OutputFormat {
+	return []OutputFormat{FormatText, FormatJSON, FormatXML, FormatCSV, FormatGrep}
+}
+
+// ListeningPortsFormats returns the output formats supported for listening
+// ports (there is no nmap XML layout for them)
+func ListeningPortsFormats() []OutputFormat {
+	return []OutputFormat{FormatText, FormatJSON, FormatCSV, FormatGrep}
+}
+
+// ParseOutputFormat converts a format name to an OutputFormat
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, format := range OutputFormats() {
+		if string(format) == name {
+			return format, nil
+		}
+	}
+
+	names := make([]string, 0, len(OutputFormats()))
+	for _, format := range OutputFormats() {
+		names = append(names, string(format))
+	}
+	return "", fmt.Errorf("unknown output format %q (supported: %s)", name, strings.Join(names, ", "))
+}
+
+// WriteNetworkScan writes a network scan report to w in the given format
+func WriteNetworkScan(w io.Writer, format OutputFormat, report *NetworkScanReport) error {
+	switch format {
+	case FormatText:
+		FprintNetworkScanReport(w, report)
+		return nil
+	case FormatJSON:
+		return WriteNetworkScanJSON(w, report)
+	case FormatXML:
+		return WriteNetworkScanXML(w, report)
+	case FormatCSV:
+		return WriteNetworkScanCSV(w, report)
+	case FormatGrep:
+		return WriteNetworkScanGrepable(w, report)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// WriteStealthyScan writes a stealth scan report to w in the given format
+func WriteStealthyScan(w io.Writer, format OutputFormat, report *StealthyScanReport) error {
+	switch format {
+	case FormatText:
+		FprintStealthyScanReport(w, report)
+		return nil
+	case FormatJSON:
+		return WriteStealthyScanJSON(w, report)
+	case FormatXML:
+		return WriteStealthyScanXML(w, report)
+	case FormatCSV:
+		return WriteStealthyScanCSV(w, report)
+	case FormatGrep:
+		return WriteStealthyScanGrepable(w, report)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// WriteListeningPorts writes listening ports to w in the given format
+func WriteListeningPorts(w io.Writer, format OutputFormat, ports []PortInfo) error {
+	switch format {
+	case FormatText:
+		FprintListeningPorts(w, ports)
+		return nil
+	case FormatJSON:
+		return WriteListeningPortsJSON(w, ports)
+	case FormatCSV:
+		return WriteListeningPortsCSV(w, ports)
+	case FormatGrep:
+		return WriteListeningPortsGrepable(w, ports)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// reportNow returns the current time for report timestamps (replaced by
+// tests to get reproducible output)
+var reportNow = time.Now
+
+// networkScanTimes returns when a network scan started and finished. Reports
+// without a start time span from the first host started to the last one
+// finished.
+func networkScanTimes(report *NetworkScanReport) (start, end time.Time) {
+	if !report.StartTime.IsZero() {
+		return report.StartTime, report.StartTime.Add(report.ScanTime)
+	}
+
+	start = reportNow()
+	for _, host := range report.Hosts {
+		if host.StartTime.IsZero() {
+			continue
+		}
+		if host.StartTime.Before(start) {
+			start = host.StartTime
+		}
+		if finished := host.StartTime.Add(host.ScanTime); finished.After(end) {
+			end = finished
+		}
+	}
+	if end.Before(start) {
+		end = start
+	}
+	return start, end
+}
+
+// scannedHosts returns the number of addresses covered by a network scan,
+// which is at least the number of live hosts
+func scannedHosts(report *NetworkScanReport) int {
+	if report.ScannedHosts < len(report.Hosts) {
+		return len(report.Hosts)
+	}
+	return report.ScannedHosts
+}
+
+// versionString describes the software behind a port like nmap's VERSION
+// column: "OpenSSH 9.0p1 (protocol 2.0)" for a signature match, else the
+// version or the first line of the banner
+func versionString(result PortResult) string {
+	if result.Product == "" {
+		if result.Version != "" {
+			return result.Version
+		}
+		return extractVersionFromBanner(result.Banner)
+	}
+
+	version := result.Product
+	if result.Version != "" {
+		version += " " + result.Version
+	}
+	if result.ExtraInfo != "" {
+		version += " (" + result.ExtraInfo + ")"
+	}
+	return version
+}
+
+// serviceLabel is the service name shown in text reports, prefixed with
+// "ssl/" for services tunnelled through TLS like nmap does
+func serviceLabel(result PortResult) string {
+	if result.TLS != nil {
+		return "ssl/" + result.Service
+	}
+	return result.Service
+}
+
+// portDetails returns the extra lines text reports print under an open
+// port (TLS certificate, HTTP and SSH fingerprints)
+func portDetails(result PortResult) []string {
+	var details []string
+	if result.TLS != nil {
+		details = append(details, "🔒 TLS: "+tlsSummary(result.TLS))
+	}
+	if result.HTTP != nil {
+		details = append(details, "🌐 HTTP: "+httpSummary(result.HTTP))
+	}
+	if result.SSH != nil {
+		details = append(details, "🔑 SSH: "+sshSummary(result.SSH))
+	}
+	return details
+}
+
+// sortedHosts returns a copy of results ordered by IP address, so exports
+// of the same network are stable and can be diffed between runs
+func sortedHosts(results []HostScanResult) []HostScanResult {
+	hosts := make([]HostScanResult, len(results))
+	copy(hosts, results)
+	sort.SliceStable(hosts, func(i, j int) bool {
+		return compareIP(hosts[i].IP, hosts[j].IP) < 0
+	})
+	return hosts
+}
+
+// compareIP orders IP addresses numerically, falling back to string order
+// for values that do not parse
+func compareIP(a, b string) int {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA == nil || ipB == nil {
+		return strings.Compare(a, b)
+	}
+	return bytes.Compare(ipA.To16(), ipB.To16())
+}
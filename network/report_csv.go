@@ -0,0 +1,123 @@
+package network
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvPortHeader is the header of port-oriented CSV exports
+var csvPortHeader = []string{
+	"ip", "hostname", "port", "protocol", "state", "service", "version", "reason", "response_time_ms",
+}
+
+// csvPingHeader is the header of ping sweep CSV exports
+var csvPingHeader = []string{
+	"ip", "hostname", "mac", "vendor", "method", "reason", "latency_ms",
+}
+
+// WriteNetworkScanCSV writes one row per open port found by the network
+// scanner. Live hosts without open ports get a single row with empty port
+// columns so they are not lost from the export. Ping sweeps get one row per
+// live host instead (see csvPingHeader).
+func WriteNetworkScanCSV(w io.Writer, report *NetworkScanReport) error {
+	if report.PingOnly {
+		return writePingSweepCSV(w, report)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvPortHeader); err != nil {
+		return err
+	}
+
+	for _, host := range sortedHosts(report.Hosts) {
+		if len(host.OpenPorts) == 0 {
+			writer.Write([]string{host.IP, host.Hostname, "", "", "", "", "", "", ""})
+			continue
+		}
+		for _, port := range host.OpenPorts {
+			writer.Write(portCSVRecord(port, host.Hostname))
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writePingSweepCSV writes one row per live host of a ping sweep
+func writePingSweepCSV(w io.Writer, report *NetworkScanReport) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvPingHeader); err != nil {
+		return err
+	}
+
+	for _, host := range sortedHosts(report.Hosts) {
+		record := []string{host.IP, host.Hostname, host.MAC, host.Vendor, "", "", ""}
+		if host.Discovery != nil {
+			record[4], record[5], record[6] = host.Discovery.Method, host.Discovery.Reason, formatMillis(host.Discovery.Latency)
+		}
+		writer.Write(record)
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteStealthyScanCSV writes one row per scanned port of a stealth scan
+func WriteStealthyScanCSV(w io.Writer, report *StealthyScanReport) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvPortHeader); err != nil {
+		return err
+	}
+
+	for _, result := range report.Results {
+		writer.Write(portCSVRecord(result, report.Hostname))
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteListeningPortsCSV writes one row per listening port
+func WriteListeningPortsCSV(w io.Writer, ports []PortInfo) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"address", "port", "state", "pid", "process", "protocol", "service"}); err != nil {
+		return err
+	}
+
+	for _, port := range ports {
+		writer.Write([]string{
+			port.LocalAddr,
+			strconv.FormatUint(uint64(port.LocalPort), 10),
+			port.State,
+			strconv.FormatInt(int64(port.PID), 10),
+			port.ProcessName,
+			port.Protocol,
+			port.Service,
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// portCSVRecord builds the CSV row of a port result
+func portCSVRecord(result PortResult, hostname string) []string {
+	return []string{
+		result.IP,
+		hostname,
+		strconv.Itoa(result.Port),
+		result.Protocol,
+		result.State,
+		result.Service,
+		versionString(result),
+		result.Reason,
+		formatMillis(result.ResponseTime),
+	}
+}
+
+// formatMillis formats a duration as milliseconds with microsecond precision
+func formatMillis(d time.Duration) string {
+	return strconv.FormatFloat(durationMillis(d), 'f', 3, 64)
+}
@@ -0,0 +1,154 @@
+package network
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteNetworkScanGrepable writes a network scan report in nmap's grepable
+// format (-oG): a Status line and a Ports line per host (Status only for
+// ping sweeps, like nmap -sn)
+func WriteNetworkScanGrepable(w io.Writer, report *NetworkScanReport) error {
+	hosts := sortedHosts(report.Hosts)
+	start, end := networkScanTimes(report)
+
+	gw := &grepableWriter{w: w}
+	gw.printf("# Nmap %s scan initiated %s as: network-toolkit scan\n", nmapCompatVersion, start.Format(time.ANSIC))
+
+	up := 0
+	for _, host := range hosts {
+		status := "Down"
+		if host.IsAlive {
+			status = "Up"
+			up++
+		}
+		gw.printf("Host: %s (%s)\tStatus: %s\n", host.IP, grepableField(host.Hostname), status)
+		if !host.IsAlive || report.PingOnly {
+			continue
+		}
+
+		var ports []string
+		for _, port := range host.OpenPorts {
+			ports = append(ports, grepablePort(port))
+		}
+		line := fmt.Sprintf("Host: %s (%s)\tPorts: %s", host.IP, grepableField(host.Hostname), strings.Join(ports, ", "))
+		line += grepableIgnored(host.ClosedPorts, host.FilteredPorts, host.OpenFilteredPorts)
+		if host.OS != "" {
+			line += "\tOS: " + grepableField(host.OS)
+		}
+		gw.printf("%s\n", line)
+	}
+
+	if report.Incomplete {
+		gw.printf("# Scan interrupted -- %d of %d IP addresses scanned\n", report.ScannedHosts, report.TotalHosts)
+	}
+	gw.printf("# Nmap done at %s -- %d IP address (%d host up) scanned in %.2f seconds\n",
+		end.Format(time.ANSIC), scannedHosts(report), up, end.Sub(start).Seconds())
+	return gw.err
+}
+
+// WriteStealthyScanGrepable writes a stealth scan report in nmap's grepable
+// format (-oG). Open and unfiltered ports are listed, other states are
+// summarised.
+func WriteStealthyScanGrepable(w io.Writer, report *StealthyScanReport) error {
+	gw := &grepableWriter{w: w}
+	gw.printf("# Nmap %s scan initiated %s as: network-toolkit stealth %s\n",
+		nmapCompatVersion, report.ScanDate.Format(time.ANSIC), report.TargetIP)
+
+	hostname := grepableField(report.Hostname)
+	gw.printf("Host: %s (%s)\tStatus: Up\n", report.TargetIP, hostname)
+
+	var ports []string
+	for _, result := range report.Results {
+		if result.State == StateOpen || result.State == StateUnfiltered {
+			ports = append(ports, grepablePort(result))
+		}
+	}
+
+	line := fmt.Sprintf("Host: %s (%s)\tPorts: %s", report.TargetIP, hostname, strings.Join(ports, ", "))
+	line += grepableIgnored(report.ClosedPorts, report.FilteredPorts, report.OpenFilteredPorts)
+	gw.printf("%s\n", line)
+
+	end := report.ScanDate.Add(report.ScanDuration)
+	gw.printf("# Nmap done at %s -- 1 IP address (1 host up) scanned in %.2f seconds\n",
+		end.Format(time.ANSIC), report.ScanDuration.Seconds())
+	return gw.err
+}
+
+// WriteListeningPortsGrepable writes listening ports in a grepable layout:
+// one line per local address, with the owning process in the owner field
+func WriteListeningPortsGrepable(w io.Writer, ports []PortInfo) error {
+	gw := &grepableWriter{w: w}
+	gw.printf("# network-toolkit listening ports at %s\n", reportNow().Format(time.ANSIC))
+
+	var addrs []string
+	byAddr := map[string][]string{}
+	for _, port := range ports {
+		if _, seen := byAddr[port.LocalAddr]; !seen {
+			addrs = append(addrs, port.LocalAddr)
+		}
+		owner := fmt.Sprintf("%s(%d)", port.ProcessName, port.PID)
+		service := port.Service
+		if service == "Unknown" {
+			service = ""
+		}
+		byAddr[port.LocalAddr] = append(byAddr[port.LocalAddr],
+			fmt.Sprintf("%d/%s/%s/%s/%s//", port.LocalPort, strings.ToLower(port.State),
+				strings.TrimSuffix(port.Protocol, "6"), grepableField(owner), grepableField(service)))
+	}
+
+	for _, addr := range addrs {
+		gw.printf("Host: %s ()\tPorts: %s\n", addr, strings.Join(byAddr[addr], ", "))
+	}
+
+	gw.printf("# Total: %d listening port(s)\n", len(ports))
+	return gw.err
+}
+
+// grepablePort formats a port entry: port/state/protocol/owner/service/rpc/version/
+func grepablePort(result PortResult) string {
+	service := result.Service
+	if service == "Unknown" {
+		service = ""
+	}
+	if result.TLS != nil {
+		service = "ssl|" + service
+	}
+	return fmt.Sprintf("%d/%s/%s//%s//%s/", result.Port, result.State, result.Protocol,
+		grepableField(strings.ToLower(service)), grepableField(versionString(result)))
+}
+
+// grepableIgnored reports the most common non-open state, like nmap does
+func grepableIgnored(closed, filtered, openFiltered int) string {
+	state, count := StateClosed, closed
+	if filtered > count {
+		state, count = StateFiltered, filtered
+	}
+	if openFiltered > count {
+		state, count = StateOpenFiltered, openFiltered
+	}
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\tIgnored State: %s (%d)", state, count)
+}
+
+// grepableField removes the characters that delimit grepable fields, the
+// same way nmap replaces them
+func grepableField(value string) string {
+	return strings.NewReplacer("/", "|", ",", ";", "\t", " ", "\n", " ", "\r", "").Replace(value)
+}
+
+// grepableWriter keeps the first write error so callers can check it once
+type grepableWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (gw *grepableWriter) printf(format string, args ...interface{}) {
+	if gw.err == nil {
+		_, gw.err = fmt.Fprintf(gw.w, format, args...)
+	}
+}
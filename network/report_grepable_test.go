@@ -0,0 +1,25 @@
+package network
+
+import "testing"
+
+func TestGrepableIgnored(t *testing.T) {
+	tests := []struct {
+		name                           string
+		closed, filtered, openFiltered int
+		want                           string
+	}{
+		{"none", 0, 0, 0, ""},
+		{"closed", 10, 2, 0, "\tIgnored State: closed (10)"},
+		{"filtered", 1, 5, 0, "\tIgnored State: filtered (5)"},
+		{"open|filtered", 3, 0, 7, "\tIgnored State: open|filtered (7)"},
+		{"tie prefers closed", 4, 4, 4, "\tIgnored State: closed (4)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grepableIgnored(tt.closed, tt.filtered, tt.openFiltered); got != tt.want {
+				t.Errorf("grepableIgnored(%d, %d, %d) = %q, want %q", tt.closed, tt.filtered, tt.openFiltered, got, tt.want)
+			}
+		})
+	}
+}
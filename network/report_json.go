@@ -0,0 +1,130 @@
+package network
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// JSONSchemaVersion is the version of the JSON export layout. It changes
+// only when a field is renamed, removed or changes meaning; new fields may
+// be added without a version change, so consumers should ignore unknown keys.
+const JSONSchemaVersion = "2.0"
+
+// Kinds of JSON documents
+const (
+	JSONKindNetworkScan    = "network_scan"
+	JSONKindStealthyScan   = "stealth_scan"
+	JSONKindListeningPorts = "listening_ports"
+)
+
+// JSONDocument is the envelope written around every JSON export.
+// Durations inside Data are expressed in milliseconds (fields ending in _ms)
+// and timestamps in RFC 3339.
+type JSONDocument struct {
+	SchemaVersion string      `json:"schema_version"`
+	Kind          string      `json:"kind"`
+	Tool          string      `json:"tool"`
+	GeneratedAt   time.Time   `json:"generated_at"`
+	Data          interface{} `json:"data"`
+}
+
+// listeningPortsJSON is the payload of a listening_ports document
+type listeningPortsJSON struct {
+	Ports []PortInfo `json:"ports"`
+}
+
+// WriteNetworkScanJSON writes a network scan report as a JSON document,
+// with hosts ordered by IP address
+func WriteNetworkScanJSON(w io.Writer, report *NetworkScanReport) error {
+	sorted := *report
+	sorted.Hosts = sortedHosts(report.Hosts)
+	return writeJSONDocument(w, JSONKindNetworkScan, sorted)
+}
+
+// WriteStealthyScanJSON writes a stealth scan report as a JSON document
+func WriteStealthyScanJSON(w io.Writer, report *StealthyScanReport) error {
+	return writeJSONDocument(w, JSONKindStealthyScan, report)
+}
+
+// WriteListeningPortsJSON writes listening ports as a JSON document
+func WriteListeningPortsJSON(w io.Writer, ports []PortInfo) error {
+	if ports == nil {
+		ports = []PortInfo{}
+	}
+	return writeJSONDocument(w, JSONKindListeningPorts, listeningPortsJSON{Ports: ports})
+}
+
+// writeJSONDocument wraps data in the versioned envelope and encodes it
+func writeJSONDocument(w io.Writer, kind string, data interface{}) error {
+	doc := JSONDocument{
+		SchemaVersion: JSONSchemaVersion,
+		Kind:          kind,
+		Tool:          "network-toolkit",
+		GeneratedAt:   reportNow(),
+		Data:          data,
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// durationMillis converts a duration to fractional milliseconds
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// MarshalJSON adds scan_time_ms to the exported fields
+func (r HostScanResult) MarshalJSON() ([]byte, error) {
+	type plain HostScanResult
+	if r.OpenPorts == nil {
+		r.OpenPorts = []PortResult{}
+	}
+	return json.Marshal(struct {
+		plain
+		ScanTimeMs float64 `json:"scan_time_ms"`
+	}{plain(r), durationMillis(r.ScanTime)})
+}
+
+// MarshalJSON adds scan_time_ms to the exported fields
+func (r NetworkScanReport) MarshalJSON() ([]byte, error) {
+	type plain NetworkScanReport
+	if r.Hosts == nil {
+		r.Hosts = []HostScanResult{}
+	}
+	return json.Marshal(struct {
+		plain
+		ScanTimeMs float64 `json:"scan_time_ms"`
+	}{plain(r), durationMillis(r.ScanTime)})
+}
+
+// MarshalJSON adds response_time_ms to the exported fields
+func (r PortResult) MarshalJSON() ([]byte, error) {
+	type plain PortResult
+	return json.Marshal(struct {
+		plain
+		ResponseTimeMs float64 `json:"response_time_ms"`
+	}{plain(r), durationMillis(r.ResponseTime)})
+}
+
+// MarshalJSON adds latency_ms to the exported fields
+func (d HostDiscovery) MarshalJSON() ([]byte, error) {
+	type plain HostDiscovery
+	return json.Marshal(struct {
+		plain
+		LatencyMs float64 `json:"latency_ms"`
+	}{plain(d), durationMillis(d.Latency)})
+}
+
+// MarshalJSON adds scan_duration_ms to the exported fields
+func (r StealthyScanReport) MarshalJSON() ([]byte, error) {
+	type plain StealthyScanReport
+	if r.Results == nil {
+		r.Results = []PortResult{}
+	}
+	return json.Marshal(struct {
+		plain
+		ScanDurationMs float64 `json:"scan_duration_ms"`
+	}{plain(r), durationMillis(r.ScanDuration)})
+}
@@ -0,0 +1,222 @@
+package network
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// fixedTime is the clock of every golden report
+var fixedTime = time.Date(2026, 1, 8, 10, 0, 0, 0, time.UTC)
+
+// testNetworkReport returns a network scan with hosts out of IP order, one
+// of them without open ports
+func testNetworkReport() *NetworkScanReport {
+	return &NetworkScanReport{
+		Network:      "10.0.0.0/28",
+		TotalHosts:   14,
+		ScannedHosts: 14,
+		StartTime:    fixedTime,
+		ScanTime:     3500 * time.Millisecond,
+		Hosts: []HostScanResult{
+			{
+				IP:       "10.0.0.10",
+				IsAlive:  true,
+				Hostname: "printer.lan.",
+				OS:       "Linux (Ubuntu)",
+				OSMatch: &OSMatch{Name: "Linux (Ubuntu)", Family: "Linux", Confidence: 90,
+					Evidence: []string{"ttl 63", "OpenSSH on 22/tcp: Linux", "nginx on 80/tcp: ubuntu"}},
+				Discovery:         &HostDiscovery{Method: DiscoveryTCPSYN, Reason: "syn-ack on 22", Latency: 900 * time.Microsecond, TTL: 63},
+				MAC:               "00:0c:29:4f:8e:35",
+				Vendor:            "VMware",
+				Protocols:         []string{"tcp", "udp"},
+				ClosedPorts:       3,
+				OpenFilteredPorts: 1,
+				TotalPorts:        6,
+				ScannedPorts:      6,
+				StartTime:         fixedTime.Add(time.Second),
+				ScanTime:          1250 * time.Millisecond,
+				OpenPorts: []PortResult{
+					{IP: "10.0.0.10", Port: 22, Protocol: "tcp", IsOpen: true, State: StateOpen, Service: "SSH",
+						Version: "SSH-2.0-OpenSSH_9.0", Banner: "SSH-2.0-OpenSSH_9.0", Reason: "syn-ack", ResponseTime: 1500 * time.Microsecond,
+						SSH: &SSHInfo{
+							Banner:        "SSH-2.0-OpenSSH_9.0",
+							KexAlgorithms: []string{"curve25519-sha256", "diffie-hellman-group1-sha1"}, HostKeyAlgorithms: []string{"ssh-ed25519"},
+							Ciphers: []string{"aes256-ctr"}, MACs: []string{"hmac-sha2-256", "hmac-md5"}, Compression: []string{"none"},
+							HostKeyType: "ssh-ed25519", HostKeyBits: 256, HostKeyFingerprint: "SHA256:6wIvrr7q9vcLPSvTjmmwhVwwQsy1p+TUSJnpgwDRBGc",
+							WeakAlgorithms: []string{"diffie-hellman-group1-sha1", "hmac-md5"},
+						}},
+					{IP: "10.0.0.10", Port: 161, Protocol: "udp", IsOpen: true, State: StateOpen, Service: "SNMP",
+						Reason: "udp-response", ResponseTime: 2 * time.Millisecond},
+				},
+			},
+			{
+				IP:            "10.0.0.2",
+				IsAlive:       true,
+				Protocols:     []string{"tcp", "udp"},
+				FilteredPorts: 6,
+				TotalPorts:    6,
+				ScannedPorts:  6,
+				StartTime:     fixedTime,
+				ScanTime:      2 * time.Second,
+				Discovery:     &HostDiscovery{Reason: "user-set"},
+			},
+		},
+	}
+}
+
+// testPingSweepReport returns a host discovery scan of a local network, one
+// host answering ARP and the router answering ICMP, without known vendor
+func testPingSweepReport() *NetworkScanReport {
+	return &NetworkScanReport{
+		Network:      "192.168.1.0/24",
+		TotalHosts:   254,
+		ScannedHosts: 254,
+		PingOnly:     true,
+		StartTime:    fixedTime,
+		ScanTime:     2 * time.Second,
+		Hosts: []HostScanResult{
+			{
+				IP:        "192.168.1.20",
+				IsAlive:   true,
+				Hostname:  "pi.lan.",
+				MAC:       "b8:27:eb:12:34:56",
+				Vendor:    "Raspberry Pi Foundation",
+				StartTime: fixedTime.Add(time.Second),
+				ScanTime:  5 * time.Millisecond,
+				Discovery: &HostDiscovery{Method: DiscoveryARP, Reason: "arp-response", Latency: 350 * time.Microsecond, MAC: "b8:27:eb:12:34:56"},
+			},
+			{
+				IP:        "192.168.1.1",
+				IsAlive:   true,
+				MAC:       "02:fc:00:00:00:05",
+				StartTime: fixedTime,
+				ScanTime:  3 * time.Millisecond,
+				Discovery: &HostDiscovery{Method: DiscoveryICMPEcho, Reason: "echo-reply", Latency: 1200 * time.Microsecond, TTL: 64},
+			},
+		},
+	}
+}
+
+// testStealthyReport returns a single-host scan with every port state
+func testStealthyReport() *StealthyScanReport {
+	return &StealthyScanReport{
+		TargetIP:      "192.168.1.20",
+		Hostname:      "server.lan.",
+		Protocols:     []string{"tcp"},
+		TotalPorts:    5,
+		OpenPorts:     2,
+		ClosedPorts:   2,
+		FilteredPorts: 1,
+		ScannedPorts:  5,
+		ScanType:      ScanTypeSYN,
+		ScanDate:      fixedTime,
+		ScanDuration:  4 * time.Second,
+		Results: []PortResult{
+			{IP: "192.168.1.20", Port: 21, Protocol: "tcp", State: StateClosed, Service: "FTP", Reason: "reset", TTL: 63, ResponseTime: time.Millisecond},
+			{IP: "192.168.1.20", Port: 22, Protocol: "tcp", State: StateFiltered, Service: "SSH", Reason: "no-response", ResponseTime: time.Second},
+			{IP: "192.168.1.20", Port: 23, Protocol: "tcp", State: StateClosed, Service: "Telnet", Reason: "reset", TTL: 63, ResponseTime: time.Millisecond},
+			{IP: "192.168.1.20", Port: 80, Protocol: "tcp", IsOpen: true, State: StateOpen, Service: "HTTP",
+				Banner: "HTTP/1.1 400 Bad Request\r\nServer: nginx/1.18.0 (Ubuntu)", Reason: "syn-ack", TTL: 63, Window: 64240, ResponseTime: 750 * time.Microsecond},
+			{IP: "192.168.1.20", Port: 443, Protocol: "tcp", IsOpen: true, State: StateOpen, Service: "http",
+				Product: "nginx", Version: "1.18.0", Reason: "syn-ack", TTL: 63, Window: 64240, ResponseTime: 900 * time.Microsecond,
+				TLS: &TLSInfo{
+					Version: "TLS 1.2", CipherSuite: "TLS_RSA_WITH_3DES_EDE_CBC_SHA",
+					Subject: "CN=server.lan", SANs: []string{"server.lan", "192.168.1.20"}, Issuer: "CN=server.lan",
+					NotBefore: fixedTime.AddDate(-2, 0, 0), NotAfter: fixedTime.AddDate(0, -1, 0),
+					KeyType: "RSA 2048", SignatureAlgorithm: "SHA256-RSA", Expired: true, SelfSigned: true,
+					Weaknesses: []string{"weak cipher TLS_RSA_WITH_3DES_EDE_CBC_SHA"},
+				},
+				HTTP: &HTTPInfo{
+					StatusCode: 200, Status: "200 OK", Server: "nginx/1.18.0 (Ubuntu)", Title: "Welcome to nginx!",
+					FaviconHash: -757223386, Technologies: []string{"PHP/8.1.2"},
+				}},
+		},
+	}
+}
+
+// testListeningPorts returns TCP and UDP listening sockets over IPv4 and IPv6
+func testListeningPorts() []PortInfo {
+	return []PortInfo{
+		{LocalAddr: "0.0.0.0", LocalPort: 22, Protocol: "tcp", State: "LISTEN", Service: "ssh", PID: 812, ProcessName: "sshd"},
+		{LocalAddr: "127.0.0.1", LocalPort: 5432, Protocol: "tcp", State: "LISTEN", Service: "postgresql", PID: 990, ProcessName: "postgres"},
+		{LocalAddr: "::", LocalPort: 5353, Protocol: "udp6", State: "LISTEN", Service: "Unknown", PID: 640, ProcessName: "avahi-daemon"},
+	}
+}
+
+// checkGolden compares got with testdata/name, rewriting it with -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+func TestReportWritersGolden(t *testing.T) {
+	reportNow = func() time.Time { return fixedTime }
+	defer func() { reportNow = time.Now }()
+
+	tests := []struct {
+		name  string
+		write func(*bytes.Buffer) error
+	}{
+		{"network_scan.json", func(b *bytes.Buffer) error { return WriteNetworkScanJSON(b, testNetworkReport()) }},
+		{"network_scan_empty.json", func(b *bytes.Buffer) error {
+			return WriteNetworkScanJSON(b, &NetworkScanReport{Network: "10.0.0.0/30", TotalHosts: 2, ScannedHosts: 1, Incomplete: true, StartTime: fixedTime})
+		}},
+		{"ping_sweep.json", func(b *bytes.Buffer) error { return WriteNetworkScanJSON(b, testPingSweepReport()) }},
+		{"stealth_scan.json", func(b *bytes.Buffer) error { return WriteStealthyScanJSON(b, testStealthyReport()) }},
+		{"stealth_scan_empty.json", func(b *bytes.Buffer) error {
+			return WriteStealthyScanJSON(b, &StealthyScanReport{TargetIP: "192.168.1.20", TotalPorts: 10, Incomplete: true, ScanDate: fixedTime})
+		}},
+		{"listening_ports.json", func(b *bytes.Buffer) error { return WriteListeningPortsJSON(b, testListeningPorts()) }},
+		{"listening_ports_empty.json", func(b *bytes.Buffer) error { return WriteListeningPortsJSON(b, nil) }},
+		{"network_scan.xml", func(b *bytes.Buffer) error { return WriteNetworkScanXML(b, testNetworkReport()) }},
+		{"ping_sweep.xml", func(b *bytes.Buffer) error { return WriteNetworkScanXML(b, testPingSweepReport()) }},
+		{"stealth_scan.xml", func(b *bytes.Buffer) error { return WriteStealthyScanXML(b, testStealthyReport()) }},
+		{"network_scan.csv", func(b *bytes.Buffer) error { return WriteNetworkScanCSV(b, testNetworkReport()) }},
+		{"ping_sweep.csv", func(b *bytes.Buffer) error { return WriteNetworkScanCSV(b, testPingSweepReport()) }},
+		{"stealth_scan.csv", func(b *bytes.Buffer) error { return WriteStealthyScanCSV(b, testStealthyReport()) }},
+		{"listening_ports.csv", func(b *bytes.Buffer) error { return WriteListeningPortsCSV(b, testListeningPorts()) }},
+		{"network_scan.gnmap", func(b *bytes.Buffer) error { return WriteNetworkScanGrepable(b, testNetworkReport()) }},
+		{"ping_sweep.gnmap", func(b *bytes.Buffer) error { return WriteNetworkScanGrepable(b, testPingSweepReport()) }},
+		{"stealth_scan.gnmap", func(b *bytes.Buffer) error { return WriteStealthyScanGrepable(b, testStealthyReport()) }},
+		{"listening_ports.gnmap", func(b *bytes.Buffer) error { return WriteListeningPortsGrepable(b, testListeningPorts()) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := tt.write(&buffer); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			checkGolden(t, tt.name, buffer.Bytes())
+		})
+	}
+}
+
+func TestSortedHosts(t *testing.T) {
+	hosts := sortedHosts([]HostScanResult{{IP: "10.0.0.10"}, {IP: "10.0.0.2"}, {IP: "9.255.255.255"}, {IP: "10.0.0.2"}})
+	want := []string{"9.255.255.255", "10.0.0.2", "10.0.0.2", "10.0.0.10"}
+	for i, host := range hosts {
+		if host.IP != want[i] {
+			t.Fatalf("sortedHosts order = %v, want %v", hosts, want)
+		}
+	}
+}
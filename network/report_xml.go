@@ -0,0 +1,620 @@
+package network
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NmapXMLOutputVersion is the nmap XML output version the writer reproduces.
+// The document claims scanner="nmap" because importers of `nmap -oX` files
+// check that attribute; the args attribute identifies this toolkit.
+const NmapXMLOutputVersion = "1.05"
+
+// nmapCompatVersion is the nmap release whose XML layout is reproduced
+const nmapCompatVersion = "7.94"
+
+// nmapRun is the <nmaprun> root element
+type nmapRun struct {
+	XMLName          xml.Name       `xml:"nmaprun"`
+	Scanner          string         `xml:"scanner,attr"`
+	Args             string         `xml:"args,attr"`
+	Start            int64          `xml:"start,attr"`
+	StartStr         string         `xml:"startstr,attr"`
+	Version          string         `xml:"version,attr"`
+	XMLOutputVersion string         `xml:"xmloutputversion,attr"`
+	ScanInfo         []nmapScanInfo `xml:"scaninfo"`
+	Verbose          nmapLevel      `xml:"verbose"`
+	Debugging        nmapLevel      `xml:"debugging"`
+	Hosts            []nmapHost     `xml:"host"`
+	RunStats         nmapRunStats   `xml:"runstats"`
+}
+
+type nmapScanInfo struct {
+	Type        string `xml:"type,attr"`
+	Protocol    string `xml:"protocol,attr"`
+	NumServices int    `xml:"numservices,attr"`
+	Services    string `xml:"services,attr"`
+}
+
+type nmapLevel struct {
+	Level int `xml:"level,attr"`
+}
+
+type nmapHost struct {
+	StartTime int64         `xml:"starttime,attr,omitempty"`
+	EndTime   int64         `xml:"endtime,attr,omitempty"`
+	Status    nmapStatus    `xml:"status"`
+	Addresses []nmapAddress `xml:"address"`
+	Hostnames nmapHostnames `xml:"hostnames"`
+	Ports     nmapPorts     `xml:"ports"`
+	OS        *nmapOS       `xml:"os,omitempty"`
+	Times     *nmapTimes    `xml:"times,omitempty"`
+}
+
+type nmapOS struct {
+	Matches []nmapOSMatch `xml:"osmatch"`
+}
+
+type nmapOSMatch struct {
+	Name     string      `xml:"name,attr"`
+	Accuracy int         `xml:"accuracy,attr"`
+	Line     int         `xml:"line,attr"`
+	Class    nmapOSClass `xml:"osclass"`
+}
+
+type nmapOSClass struct {
+	Vendor   string `xml:"vendor,attr"`
+	OSFamily string `xml:"osfamily,attr"`
+	Accuracy int    `xml:"accuracy,attr"`
+}
+
+type nmapStatus struct {
+	State     string `xml:"state,attr"`
+	Reason    string `xml:"reason,attr"`
+	ReasonTTL int    `xml:"reason_ttl,attr"`
+}
+
+type nmapAddress struct {
+	Addr     string `xml:"addr,attr"`
+	AddrType string `xml:"addrtype,attr"`
+	Vendor   string `xml:"vendor,attr,omitempty"`
+}
+
+type nmapHostnames struct {
+	Hostnames []nmapHostname `xml:"hostname"`
+}
+
+type nmapHostname struct {
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type nmapPorts struct {
+	ExtraPorts []nmapExtraPorts `xml:"extraports"`
+	Ports      []nmapPort       `xml:"port"`
+}
+
+type nmapExtraPorts struct {
+	State        string             `xml:"state,attr"`
+	Count        int                `xml:"count,attr"`
+	ExtraReasons []nmapExtraReasons `xml:"extrareasons"`
+}
+
+type nmapExtraReasons struct {
+	Reason string `xml:"reason,attr"`
+	Count  int    `xml:"count,attr"`
+}
+
+type nmapPort struct {
+	Protocol string       `xml:"protocol,attr"`
+	PortID   int          `xml:"portid,attr"`
+	State    nmapStatus   `xml:"state"`
+	Service  *nmapService `xml:"service,omitempty"`
+	Scripts  []nmapScript `xml:"script"`
+}
+
+type nmapScript struct {
+	ID     string `xml:"id,attr"`
+	Output string `xml:"output,attr"`
+}
+
+type nmapService struct {
+	Name      string `xml:"name,attr"`
+	Product   string `xml:"product,attr,omitempty"`
+	Version   string `xml:"version,attr,omitempty"`
+	ExtraInfo string `xml:"extrainfo,attr,omitempty"`
+	OSType    string `xml:"ostype,attr,omitempty"`
+	Tunnel    string `xml:"tunnel,attr,omitempty"`
+	Method    string `xml:"method,attr"`
+	Conf      int    `xml:"conf,attr"`
+}
+
+type nmapTimes struct {
+	SRTT   int64 `xml:"srtt,attr"`
+	RTTVar int64 `xml:"rttvar,attr"`
+	To     int64 `xml:"to,attr"`
+}
+
+type nmapRunStats struct {
+	Finished nmapFinished  `xml:"finished"`
+	Hosts    nmapHostStats `xml:"hosts"`
+}
+
+type nmapFinished struct {
+	Time    int64  `xml:"time,attr"`
+	TimeStr string `xml:"timestr,attr"`
+	Elapsed string `xml:"elapsed,attr"`
+	Summary string `xml:"summary,attr"`
+	Exit    string `xml:"exit,attr"`
+}
+
+type nmapHostStats struct {
+	Up    int `xml:"up,attr"`
+	Down  int `xml:"down,attr"`
+	Total int `xml:"total,attr"`
+}
+
+// WriteNetworkScanXML writes a network scan report in nmap's XML format (-oX)
+func WriteNetworkScanXML(w io.Writer, report *NetworkScanReport) error {
+	hosts := sortedHosts(report.Hosts)
+	start, end := networkScanTimes(report)
+
+	run := newNmapRun("network-toolkit scan "+report.Network, start)
+	incomplete := report.Incomplete
+	numServices := 0
+	var protocols []string
+	for _, host := range hosts {
+		run.Hosts = append(run.Hosts, networkHostToXML(host))
+		if host.IsAlive {
+			run.RunStats.Hosts.Up++
+		} else {
+			run.RunStats.Hosts.Down++
+		}
+		if host.TotalPorts > numServices {
+			numServices = host.TotalPorts
+		}
+		incomplete = incomplete || host.Incomplete
+		if len(host.Protocols) > len(protocols) {
+			protocols = host.Protocols
+		}
+	}
+	if !report.PingOnly {
+		// nmap -sn has no <scaninfo>
+		run.ScanInfo = nmapScanInfos(protocols, ScanTypeConnect, numServices, "")
+	}
+	// Only live hosts are kept; the other scanned addresses were down
+	run.RunStats.Hosts.Total = scannedHosts(report)
+	run.RunStats.Hosts.Down = run.RunStats.Hosts.Total - run.RunStats.Hosts.Up
+	run.RunStats.Finished = nmapFinishedStats(start, end, run.RunStats.Hosts, incomplete)
+
+	return encodeNmapRun(w, run)
+}
+
+// WriteStealthyScanXML writes a stealth scan report in nmap's XML format (-oX)
+func WriteStealthyScanXML(w io.Writer, report *StealthyScanReport) error {
+	start := report.ScanDate
+	end := start.Add(report.ScanDuration)
+
+	run := newNmapRun("network-toolkit stealth "+report.TargetIP, start)
+	run.ScanInfo = nmapScanInfos(report.Protocols, report.ScanType, report.TotalPorts, stealthyPortRange(report))
+
+	host := nmapHost{
+		StartTime: start.Unix(),
+		EndTime:   end.Unix(),
+		Status:    nmapStatus{State: "up", Reason: "user-set"},
+		Addresses: []nmapAddress{xmlAddress(report.TargetIP)},
+		Hostnames: xmlHostnames(report.Hostname),
+	}
+
+	extra := map[string]*nmapExtraPorts{}
+	var srtt time.Duration
+	for _, result := range report.Results {
+		if result.State == StateOpen {
+			host.Ports.Ports = append(host.Ports.Ports, xmlPort(result))
+			if srtt == 0 || result.ResponseTime < srtt {
+				srtt = result.ResponseTime
+			}
+			continue
+		}
+
+		// The other states are summarised like nmap does
+		group, ok := extra[result.State]
+		if !ok {
+			group = &nmapExtraPorts{State: result.State}
+			extra[result.State] = group
+		}
+		group.Count++
+		addExtraReason(group, xmlReason(result.Reason))
+	}
+	for _, state := range []string{StateClosed, StateFiltered, StateOpenFiltered, StateUnfiltered} {
+		if group, ok := extra[state]; ok {
+			host.Ports.ExtraPorts = append(host.Ports.ExtraPorts, *group)
+		}
+	}
+	if srtt > 0 {
+		host.Times = &nmapTimes{SRTT: srtt.Microseconds(), To: (srtt * 4).Microseconds()}
+	}
+
+	run.Hosts = []nmapHost{host}
+	run.RunStats.Hosts = nmapHostStats{Up: 1, Total: 1}
+	run.RunStats.Finished = nmapFinishedStats(start, end, run.RunStats.Hosts, report.Incomplete)
+
+	return encodeNmapRun(w, run)
+}
+
+// networkHostToXML converts a network scan host to an nmap <host> element
+func networkHostToXML(host HostScanResult) nmapHost {
+	element := nmapHost{
+		Status:    nmapStatus{State: "down", Reason: "no-response"},
+		Addresses: []nmapAddress{xmlAddress(host.IP)},
+		Hostnames: xmlHostnames(host.Hostname),
+	}
+	if !host.StartTime.IsZero() {
+		element.StartTime = host.StartTime.Unix()
+		element.EndTime = host.StartTime.Add(host.ScanTime).Unix()
+	}
+	if !host.IsAlive {
+		return element
+	}
+	if host.MAC != "" {
+		// nmap writes MAC addresses in upper case
+		element.Addresses = append(element.Addresses, nmapAddress{Addr: strings.ToUpper(host.MAC), AddrType: "mac", Vendor: host.Vendor})
+	}
+	element.Status = nmapStatus{State: "up", Reason: "syn-ack"}
+	if host.Discovery != nil {
+		// nmap names the reason only ("syn-ack on 443" is "syn-ack")
+		reason, _, _ := strings.Cut(host.Discovery.Reason, " on ")
+		element.Status = nmapStatus{State: "up", Reason: reason, ReasonTTL: host.Discovery.TTL}
+	}
+
+	for _, port := range host.OpenPorts {
+		element.Ports.Ports = append(element.Ports.Ports, xmlPort(port))
+	}
+	if host.OSMatch != nil {
+		element.OS = xmlOS(host.OSMatch)
+	}
+
+	// The network scanner only keeps open ports; the others are counted
+	if host.ClosedPorts > 0 {
+		element.Ports.ExtraPorts = append(element.Ports.ExtraPorts, nmapExtraPorts{State: StateClosed, Count: host.ClosedPorts})
+	}
+	if host.FilteredPorts > 0 {
+		element.Ports.ExtraPorts = append(element.Ports.ExtraPorts, nmapExtraPorts{State: StateFiltered, Count: host.FilteredPorts})
+	}
+	if host.OpenFilteredPorts > 0 {
+		element.Ports.ExtraPorts = append(element.Ports.ExtraPorts, nmapExtraPorts{State: StateOpenFiltered, Count: host.OpenFilteredPorts})
+	}
+
+	return element
+}
+
+// xmlPort converts a port result to an nmap <port> element
+func xmlPort(result PortResult) nmapPort {
+	return nmapPort{
+		Protocol: result.Protocol,
+		PortID:   result.Port,
+		State:    nmapStatus{State: result.State, Reason: xmlReason(result.Reason), ReasonTTL: result.TTL},
+		Service:  xmlPortService(result),
+		Scripts:  xmlScripts(result),
+	}
+}
+
+// xmlScripts reports the details of a port (TLS certificate, ...) as the
+// output of the NSE scripts nmap uses for them
+func xmlScripts(result PortResult) []nmapScript {
+	var scripts []nmapScript
+	if result.TLS != nil {
+		scripts = append(scripts, nmapScript{ID: "ssl-cert", Output: xmlSSLCert(result.TLS)})
+	}
+	if result.HTTP != nil {
+		scripts = append(scripts, nmapScript{ID: "http-title", Output: xmlHTTPTitle(result.HTTP)})
+		if result.HTTP.Server != "" {
+			scripts = append(scripts, nmapScript{ID: "http-server-header", Output: result.HTTP.Server})
+		}
+		if result.HTTP.FaviconHash != 0 {
+			scripts = append(scripts, nmapScript{ID: "http-favicon", Output: "Favicon hash: " + strconv.Itoa(int(result.HTTP.FaviconHash))})
+		}
+	}
+	if result.SSH != nil {
+		if result.SSH.HostKeyFingerprint != "" {
+			scripts = append(scripts, nmapScript{ID: "ssh-hostkey", Output: fmt.Sprintf("%d %s (%s)",
+				result.SSH.HostKeyBits, result.SSH.HostKeyFingerprint, result.SSH.HostKeyType)})
+		}
+		scripts = append(scripts, nmapScript{ID: "ssh2-enum-algos", Output: xmlSSHAlgorithms(result.SSH)})
+	}
+	return scripts
+}
+
+// xmlHTTPTitle formats the page title like the http-title script
+func xmlHTTPTitle(h *HTTPInfo) string {
+	switch {
+	case h.Redirect != "":
+		return "Did not follow redirect to " + h.Redirect
+	case h.Title == "":
+		return "Site doesn't have a title."
+	}
+	return h.Title
+}
+
+// xmlSSHAlgorithms formats the algorithm lists like the ssh2-enum-algos
+// script, followed by the weak ones
+func xmlSSHAlgorithms(s *SSHInfo) string {
+	var lines []string
+	for _, section := range []struct {
+		name  string
+		names []string
+	}{
+		{"kex_algorithms", s.KexAlgorithms},
+		{"server_host_key_algorithms", s.HostKeyAlgorithms},
+		{"encryption_algorithms", s.Ciphers},
+		{"mac_algorithms", s.MACs},
+		{"compression_algorithms", s.Compression},
+	} {
+		lines = append(lines, fmt.Sprintf("%s: (%d)", section.name, len(section.names)))
+		for _, name := range section.names {
+			lines = append(lines, "    "+name)
+		}
+	}
+	if len(s.WeakAlgorithms) > 0 {
+		lines = append(lines, "weak: "+strings.Join(s.WeakAlgorithms, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// xmlSSLCert formats a certificate like the ssl-cert script
+func xmlSSLCert(t *TLSInfo) string {
+	lines := []string{
+		"Subject: " + t.Subject,
+		"Subject Alternative Name: " + strings.Join(t.SANs, ", "),
+		"Issuer: " + t.Issuer,
+		"Public Key type: " + t.KeyType,
+		"Signature Algorithm: " + t.SignatureAlgorithm,
+		"Not valid before: " + t.NotBefore.UTC().Format("2006-01-02T15:04:05"),
+		"Not valid after:  " + t.NotAfter.UTC().Format("2006-01-02T15:04:05"),
+		"Protocol: " + t.Version + ", " + t.CipherSuite,
+	}
+	if issues := t.Issues(); len(issues) > 0 {
+		lines = append(lines, "Issues: "+strings.Join(issues, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// newNmapRun creates the root element shared by both writers
+func newNmapRun(args string, start time.Time) nmapRun {
+	return nmapRun{
+		Scanner:          "nmap",
+		Args:             args,
+		Start:            start.Unix(),
+		StartStr:         start.Format(time.ANSIC),
+		Version:          nmapCompatVersion,
+		XMLOutputVersion: NmapXMLOutputVersion,
+	}
+}
+
+// nmapScanInfos builds one <scaninfo> per scanned protocol (TCP for
+// results without protocols), splitting numServices evenly between them.
+// TCP is scanned with tcpScanType ("" means connect).
+func nmapScanInfos(protocols []string, tcpScanType string, numServices int, services string) []nmapScanInfo {
+	if len(protocols) == 0 {
+		protocols = []string{"tcp"}
+	}
+
+	infos := make([]nmapScanInfo, 0, len(protocols))
+	for _, protocol := range protocols {
+		scanType := tcpScanType
+		if scanType == "" {
+			scanType = ScanTypeConnect
+		}
+		if protocol == "udp" {
+			scanType = "udp"
+		}
+		infos = append(infos, nmapScanInfo{
+			Type:        scanType,
+			Protocol:    protocol,
+			NumServices: numServices / len(protocols),
+			Services:    services,
+		})
+	}
+	return infos
+}
+
+// nmapFinishedStats builds the <finished> element
+func nmapFinishedStats(start, end time.Time, hosts nmapHostStats, incomplete bool) nmapFinished {
+	elapsed := end.Sub(start).Seconds()
+	finished := nmapFinished{
+		Time:    end.Unix(),
+		TimeStr: end.Format(time.ANSIC),
+		Elapsed: strconv.FormatFloat(elapsed, 'f', 2, 64),
+		Summary: fmt.Sprintf("Nmap done at %s; %d IP address (%d host up) scanned in %.2f seconds",
+			end.Format(time.ANSIC), hosts.Total, hosts.Up, elapsed),
+		Exit: "success",
+	}
+	if incomplete {
+		finished.Exit = "error"
+	}
+	return finished
+}
+
+// encodeNmapRun writes the XML declaration, doctype and document
+func encodeNmapRun(w io.Writer, run nmapRun) error {
+	if _, err := io.WriteString(w, xml.Header+"<!DOCTYPE nmaprun>\n"); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(run); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// xmlAddress builds an <address> element with the right address type
+func xmlAddress(ip string) nmapAddress {
+	addrType := "ipv4"
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+		addrType = "ipv6"
+	}
+	return nmapAddress{Addr: ip, AddrType: addrType}
+}
+
+// xmlHostnames builds the <hostnames> element from a reverse DNS name
+func xmlHostnames(hostname string) nmapHostnames {
+	if hostname == "" {
+		return nmapHostnames{}
+	}
+	return nmapHostnames{Hostnames: []nmapHostname{{Name: strings.TrimSuffix(hostname, "."), Type: "PTR"}}}
+}
+
+// xmlOS converts an OS guess to an nmap <os> element
+func xmlOS(match *OSMatch) *nmapOS {
+	return &nmapOS{Matches: []nmapOSMatch{{
+		Name:     match.Name,
+		Accuracy: match.Confidence,
+		Class:    nmapOSClass{Vendor: osVendor(match.Family), OSFamily: match.Family, Accuracy: match.Confidence},
+	}}}
+}
+
+// osVendor returns the vendor of an OS family, as nmap's osclass reports it
+func osVendor(family string) string {
+	switch family {
+	case "Windows":
+		return "Microsoft"
+	case "macOS":
+		return "Apple"
+	case "IOS":
+		return "Cisco"
+	case "Solaris":
+		return "Oracle"
+	}
+	return family
+}
+
+// xmlPortService builds the <service> element of a port, from the service
+// probe match when there is one and from the banner otherwise
+func xmlPortService(result PortResult) *nmapService {
+	service := xmlService(result.Service, result.Version, result.Banner)
+	if result.Product != "" {
+		service = &nmapService{
+			Name:      strings.ToLower(result.Service),
+			Product:   result.Product,
+			Version:   result.Version,
+			ExtraInfo: result.ExtraInfo,
+			OSType:    result.OSType,
+			Method:    "probed",
+			Conf:      10,
+		}
+	}
+	if result.TLS != nil {
+		service.Tunnel = "ssl"
+	}
+	return service
+}
+
+// xmlService builds the <service> element. Names follow nmap's lowercase
+// convention; a banner-derived version marks the service as probed.
+func xmlService(service, version, banner string) *nmapService {
+	if service == "" || service == "Unknown" {
+		service = "unknown"
+	}
+
+	element := &nmapService{Name: strings.ToLower(service), Method: "table", Conf: 3}
+	if version == "" && banner != "" {
+		version = extractVersionFromBanner(banner)
+	}
+	if version == "" {
+		return element
+	}
+
+	element.Method = "probed"
+	element.Conf = 10
+	if product, productVersion, extraInfo := splitProductVersion(banner); product != "" {
+		element.Product, element.Version, element.ExtraInfo = product, productVersion, extraInfo
+	} else if product, productVersion, extraInfo := splitProductVersion(version); product != "" {
+		element.Product, element.Version, element.ExtraInfo = product, productVersion, extraInfo
+	} else {
+		element.Product = version
+	}
+	return element
+}
+
+var (
+	// SSH identification string: SSH-2.0-OpenSSH_9.0p1 Ubuntu-3ubuntu0.1
+	sshBannerRegexp = regexp.MustCompile(`^SSH-[\d.]+-([A-Za-z][\w.]*?)[_-]v?(\d[\w.]*)(?:\s+(.+))?$`)
+	// HTTP header or banner token: Apache/2.4.41 (Ubuntu), nginx/1.18.0
+	slashVersionRegexp = regexp.MustCompile(`([A-Za-z][\w.-]*)/v?(\d+(?:\.\d+)+[\w.-]*)(?:\s+\(([^)]*)\))?`)
+	// Name followed by a dotted version: 220 (vsFTPd 3.0.3), ProFTPD 1.3.5 Server
+	spaceVersionRegexp = regexp.MustCompile(`([A-Za-z][\w-]*[A-Za-z])[ _]v?(\d+(?:\.\d+)+[\w.-]*)`)
+)
+
+// splitProductVersion extracts product, version and extra information from
+// the banner shapes the toolkit recognises (SSH, HTTP Server headers and
+// "Name 1.2.3" greetings). It returns empty strings when nothing matches.
+func splitProductVersion(banner string) (product, version, extraInfo string) {
+	banner = strings.TrimSpace(banner)
+	if banner == "" {
+		return "", "", ""
+	}
+
+	firstLine := strings.TrimSpace(strings.SplitN(banner, "\n", 2)[0])
+	if match := sshBannerRegexp.FindStringSubmatch(firstLine); match != nil {
+		return match[1], match[2], match[3]
+	}
+
+	// Prefer the Server header of HTTP responses
+	for _, line := range strings.Split(banner, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "Server:"); ok {
+			if match := slashVersionRegexp.FindStringSubmatch(value); match != nil {
+				return match[1], match[2], match[3]
+			}
+			return strings.TrimSpace(value), "", ""
+		}
+	}
+
+	if match := slashVersionRegexp.FindStringSubmatch(firstLine); match != nil {
+		return match[1], match[2], match[3]
+	}
+	if match := spaceVersionRegexp.FindStringSubmatch(firstLine); match != nil {
+		return match[1], match[2], ""
+	}
+	return "", "", ""
+}
+
+// xmlReason maps the toolkit's detection reasons onto nmap reason names
+func xmlReason(reason string) string {
+	if reason == "" {
+		return "no-response"
+	}
+	return reason
+}
+
+// addExtraReason counts a reason inside an <extraports> group
+func addExtraReason(group *nmapExtraPorts, reason string) {
+	for i := range group.ExtraReasons {
+		if group.ExtraReasons[i].Reason == reason {
+			group.ExtraReasons[i].Count++
+			return
+		}
+	}
+	group.ExtraReasons = append(group.ExtraReasons, nmapExtraReasons{Reason: reason, Count: 1})
+}
+
+// stealthyPortRange returns the scanned range as written in scaninfo
+func stealthyPortRange(report *StealthyScanReport) string {
+	if len(report.Results) == 0 {
+		return ""
+	}
+	first := report.Results[0].Port
+	last := report.Results[len(report.Results)-1].Port
+	if first == last {
+		return strconv.Itoa(first)
+	}
+	return fmt.Sprintf("%d-%d", first, last)
+}
@@ -0,0 +1,47 @@
+package network
+
+import "testing"
+
+func TestSplitProductVersion(t *testing.T) {
+	tests := []struct {
+		banner                      string
+		product, version, extraInfo string
+	}{
+		{"SSH-2.0-OpenSSH_9.0", "OpenSSH", "9.0", ""},
+		{"SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.1", "OpenSSH", "8.9p1", "Ubuntu-3ubuntu0.1"},
+		{"SSH-2.0-dropbear_2020.81", "dropbear", "2020.81", ""},
+		{"HTTP/1.1 200 OK\r\nServer: Apache/2.4.41 (Ubuntu)\r\n", "Apache", "2.4.41", "Ubuntu"},
+		{"HTTP/1.0 400 Bad Request\r\nServer: cloudflare\r\n", "cloudflare", "", ""},
+		{"220 (vsFTPd 3.0.3)", "vsFTPd", "3.0.3", ""},
+		{"220 ProFTPD 1.3.5 Server (Debian)", "ProFTPD", "1.3.5", ""},
+		{"nginx/1.18.0", "nginx", "1.18.0", ""},
+		{"+OK ready", "", "", ""},
+		{"", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		product, version, extraInfo := splitProductVersion(tt.banner)
+		if product != tt.product || version != tt.version || extraInfo != tt.extraInfo {
+			t.Errorf("splitProductVersion(%q) = %q, %q, %q; want %q, %q, %q",
+				tt.banner, product, version, extraInfo, tt.product, tt.version, tt.extraInfo)
+		}
+	}
+}
+
+func TestXMLService(t *testing.T) {
+	service := xmlService("SSH", "SSH-2.0-OpenSSH_9.0", "SSH-2.0-OpenSSH_9.0")
+	if service.Name != "ssh" || service.Product != "OpenSSH" || service.Version != "9.0" || service.Method != "probed" {
+		t.Errorf("xmlService(SSH) = %+v", *service)
+	}
+
+	// Banners without a recognisable version keep the whole first line
+	service = xmlService("Redis", "", "-NOAUTH Authentication required.")
+	if service.Product != "-NOAUTH Authentication required." || service.Version != "" {
+		t.Errorf("xmlService(Redis) = %+v", *service)
+	}
+
+	service = xmlService("Unknown", "", "")
+	if service.Name != "unknown" || service.Method != "table" || service.Product != "" {
+		t.Errorf("xmlService(Unknown) = %+v", *service)
+	}
+}
@@ -0,0 +1,223 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Port states reported by the probers
+const (
+	StateOpen     = "open"
+	StateClosed   = "closed"
+	StateFiltered = "filtered"
+)
+
+// Protocol selections accepted by the scan configs
+const (
+	ProtocolTCP  = "tcp"
+	ProtocolUDP  = "udp"
+	ProtocolBoth = "both"
+)
+
+// PortResult is the result of probing one port. Every Prober produces it,
+// whatever the protocol or probe technique.
+type PortResult struct {
+	IP           string        `json:"ip"`
+	Port         int           `json:"port"`
+	Protocol     string        `json:"protocol"` // tcp, udp
+	IsOpen       bool          `json:"is_open"`
+	State        string        `json:"state"` // open, closed, filtered, open|filtered
+	Service      string        `json:"service"`
+	Product      string        `json:"product,omitempty"`    // Detected by service probes (e.g., OpenSSH)
+	Version      string        `json:"version,omitempty"`    // Product version, or the banner's first line without a signature match
+	ExtraInfo    string        `json:"extra_info,omitempty"` // Detected by service probes (e.g., protocol 2.0)
+	OSType       string        `json:"os_type,omitempty"`    // Operating system hinted by the service signature
+	Banner       string        `json:"banner,omitempty"`
+	TLS          *TLSInfo      `json:"tls,omitempty"`    // Set when the port completed a TLS handshake
+	HTTP         *HTTPInfo     `json:"http,omitempty"`   // Set for web servers
+	SSH          *SSHInfo      `json:"ssh,omitempty"`    // Set for SSH servers
+	Reason       string        `json:"reason"`           // Detection reason (nmap --reason)
+	TTL          int           `json:"ttl,omitempty"`    // TTL of the answer, known to the raw scans
+	Window       int           `json:"window,omitempty"` // TCP window of the SYN-ACK, known to the raw scans
+	ResponseTime time.Duration `json:"-"`                // Exported as response_time_ms
+}
+
+// PortScanResult is the port result of the network scanner
+type PortScanResult = PortResult
+
+// StealthyScanResult is the port result of the single-host scanner
+type StealthyScanResult = PortResult
+
+// Prober probes a single port using one technique (connect, banner grab,
+// UDP, ...). Probe must be safe for concurrent use and should return
+// promptly once ctx is cancelled.
+type Prober interface {
+	// Protocol returns the transport protocol probed ("tcp" or "udp")
+	Protocol() string
+	// Probe probes port on ip and classifies its state
+	Probe(ctx context.Context, ip string, port int) PortResult
+}
+
+// Scanner runs a Prober over a list of ports with a pool of workers
+type Scanner struct {
+	Prober  Prober
+	Threads int
+	Delay   time.Duration // Wait between two probes, across workers (0: none)
+
+	timeouts *adaptiveTimeout // Tunes the prober's timeout per probe (nil: fixed)
+}
+
+// ScanPorts probes every port on ip and returns the results sorted by port.
+// onResult, when not nil, is called for each completed probe from a single
+// goroutine. When ctx is cancelled no new ports are dispatched, in-flight
+// probes are drained and the results collected so far are returned.
+func (s Scanner) ScanPorts(ctx context.Context, ip string, ports []int, onResult func(PortResult)) []PortResult {
+	threads := s.Threads
+	if threads < 1 {
+		threads = 1
+	}
+
+	var wg sync.WaitGroup
+	portChan := make(chan int)
+	resultChan := make(chan PortResult, threads)
+
+	// Workers
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for port := range portChan {
+				prober := s.Prober
+				if tunable, ok := prober.(timeoutProber); ok && s.timeouts != nil {
+					prober = tunable.withTimeout(s.timeouts.current())
+				}
+				result := prober.Probe(ctx, ip, port)
+				if s.timeouts != nil {
+					s.timeouts.observe(result)
+				}
+				// A probe cut short by cancellation says nothing about the port
+				if ctx.Err() != nil {
+					continue
+				}
+				resultChan <- result
+			}
+		}()
+	}
+
+	// Dispatch ports until done or cancelled
+	go func() {
+		defer close(portChan)
+		for i, port := range ports {
+			if i > 0 && s.Delay > 0 {
+				select {
+				case <-time.After(s.Delay):
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case portChan <- port:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	results := make([]PortResult, 0, len(ports))
+	for result := range resultChan {
+		results = append(results, result)
+		if onResult != nil {
+			onResult(result)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Port < results[j].Port
+	})
+	return results
+}
+
+// defaultTCPProber returns the prober used when a config does not set one
+func defaultTCPProber(timeout time.Duration, serviceDetection bool) Prober {
+	if serviceDetection {
+		return ServiceProber{Timeout: timeout}
+	}
+	return ConnectProber{Timeout: timeout}
+}
+
+// ParseProtocol normalizes a protocol selection ("" means tcp)
+func ParseProtocol(name string) (string, error) {
+	switch protocol := strings.ToLower(strings.TrimSpace(name)); protocol {
+	case "":
+		return ProtocolTCP, nil
+	case ProtocolTCP, ProtocolUDP, ProtocolBoth:
+		return protocol, nil
+	}
+	return "", fmt.Errorf("unknown protocol %q (supported: tcp, udp, both)", name)
+}
+
+// scanProbers returns the probers of a scan config for a protocol already
+// normalized by ParseProtocol, probing TCP with tcp and UDP with the timeout
+// and retransmissions of timing. A custom prober replaces the selection.
+func scanProbers(custom Prober, protocol string, tcp Prober, timing TimingTemplate) []Prober {
+	if custom != nil {
+		return []Prober{custom}
+	}
+
+	udp := UDPProber{Timeout: timing.Timeout, Retries: timing.Retries}
+	switch protocol {
+	case ProtocolUDP:
+		return []Prober{udp}
+	case ProtocolBoth:
+		return []Prober{tcp, udp}
+	default:
+		return []Prober{tcp}
+	}
+}
+
+// probersProtocols lists the transport protocols covered by probers
+func probersProtocols(probers []Prober) []string {
+	var protocols []string
+	for _, prober := range probers {
+		protocols = append(protocols, prober.Protocol())
+	}
+	return protocols
+}
+
+// scanWithProbers runs each prober over its protocol's ports in turn, at
+// the pace of timing, and returns the merged results sorted by port, TCP
+// before UDP. Ports are dispatched most likely open first, so an
+// interrupted scan has already covered the ports that matter most.
+func scanWithProbers(ctx context.Context, probers []Prober, timing TimingTemplate, ip string, ports PortSpec, onResult func(PortResult)) []PortResult {
+	scan := func(prober Prober) []PortResult {
+		// Each protocol measures its own round-trip times
+		scanner := Scanner{Prober: prober, Threads: timing.Parallelism, Delay: timing.ScanDelay, timeouts: newAdaptiveTimeout(timing)}
+		protocol := prober.Protocol()
+		return scanner.ScanPorts(ctx, ip, rankPorts(protocol, ports.For(protocol)), onResult)
+	}
+	if len(probers) == 1 {
+		return scan(probers[0])
+	}
+
+	var results []PortResult
+	for _, prober := range probers {
+		results = append(results, scan(prober)...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Port != results[j].Port {
+			return results[i].Port < results[j].Port
+		}
+		return results[i].Protocol < results[j].Protocol
+	})
+	return results
+}
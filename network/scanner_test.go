@@ -0,0 +1,55 @@
+package network
+
+import "testing"
+
+func TestParseProtocol(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"", ProtocolTCP, false},
+		{"tcp", ProtocolTCP, false},
+		{"UDP", ProtocolUDP, false},
+		{" udp ", ProtocolUDP, false},
+		{"both", ProtocolBoth, false},
+		{"sctp", "", true},
+		{"tcp,udp", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseProtocol(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseProtocol(%q) = %q, %v; want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestScanProbers(t *testing.T) {
+	tests := []struct {
+		protocol string
+		want     []string
+	}{
+		{ProtocolTCP, []string{"tcp"}},
+		{ProtocolUDP, []string{"udp"}},
+		{ProtocolBoth, []string{"tcp", "udp"}},
+	}
+
+	for _, tt := range tests {
+		got := probersProtocols(scanProbers(nil, tt.protocol, ConnectProber{}, TimingTemplates[DefaultTiming]))
+		if len(got) != len(tt.want) {
+			t.Fatalf("scanProbers(%q) protocols = %v, want %v", tt.protocol, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("scanProbers(%q) protocols = %v, want %v", tt.protocol, got, tt.want)
+			}
+		}
+	}
+
+	// A custom prober replaces the protocol selection
+	got := scanProbers(UDPProber{}, ProtocolTCP, ConnectProber{}, TimingTemplates[DefaultTiming])
+	if len(got) != 1 || got[0].Protocol() != "udp" {
+		t.Errorf("custom prober not used: %v", probersProtocols(got))
+	}
+}
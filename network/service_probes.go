@@ -0,0 +1,502 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serviceProbesData is the embedded probe and signature database
+//
+//go:embed data/service-probes.txt
+var serviceProbesData string
+
+// maxProbeResponse caps the bytes read in answer to a probe
+const maxProbeResponse = 16 * 1024
+
+// maxActiveProbes caps the probes sent to an open port after the NULL probe
+const maxActiveProbes = 4
+
+// serviceProbe is a request sent to a port and the signatures matching the
+// replies it triggers
+type serviceProbe struct {
+	protocol string // "tcp" or "udp"
+	name     string
+	payload  []byte
+	ports    map[int]bool // Ports the probe targets first
+	fallback bool         // Also sent to ports it does not target
+	matches  []serviceMatch
+}
+
+// serviceMatch is a signature: a pattern and the templates describing the
+// service it identifies
+type serviceMatch struct {
+	service  string
+	pattern  *regexp.Regexp
+	soft     bool // Names the service only
+	product  string
+	version  string
+	info     string
+	osType   string
+	hostname string
+}
+
+// serviceInfo is what a signature learned about a service
+type serviceInfo struct {
+	service   string
+	product   string
+	version   string
+	extraInfo string
+	osType    string
+	hostname  string
+	soft      bool
+}
+
+// serviceProbes returns the parsed embedded database
+var serviceProbes = sync.OnceValues(func() ([]*serviceProbe, error) {
+	return parseServiceProbes(serviceProbesData)
+})
+
+// parseServiceProbes parses the probe database format described at the top
+// of data/service-probes.txt
+func parseServiceProbes(data string) ([]*serviceProbe, error) {
+	var probes []*serviceProbe
+	var current *serviceProbe
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		directive, rest, _ := strings.Cut(text, " ")
+
+		var err error
+		switch {
+		case directive == "Probe":
+			current, err = parseProbeLine(rest)
+			if err == nil {
+				probes = append(probes, current)
+			}
+		case current == nil:
+			err = fmt.Errorf("%s before the first Probe", directive)
+		case directive == "ports":
+			var spec PortSpec
+			if spec, err = ParsePortSpec(rest); err == nil {
+				current.ports = make(map[int]bool)
+				for _, port := range spec.All() {
+					current.ports[port] = true
+				}
+			}
+		case directive == "fallback":
+			current.fallback = true
+		case directive == "match" || directive == "softmatch":
+			var match serviceMatch
+			if match, err = parseMatchLine(rest); err == nil {
+				match.soft = directive == "softmatch"
+				current.matches = append(current.matches, match)
+			}
+		default:
+			err = fmt.Errorf("unknown directive %q", directive)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("service probes line %d: %v", line, err)
+		}
+	}
+	return probes, scanner.Err()
+}
+
+// parseProbeLine parses "<TCP|UDP> <name> q|payload|"
+func parseProbeLine(rest string) (*serviceProbe, error) {
+	fields := strings.SplitN(rest, " ", 3)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("malformed Probe %q", rest)
+	}
+	protocol := strings.ToLower(fields[0])
+	if protocol != ProtocolTCP && protocol != ProtocolUDP {
+		return nil, fmt.Errorf("unknown probe protocol %q", fields[0])
+	}
+
+	payload, tail, err := cutDelimited(fields[2], 'q')
+	if err != nil || strings.TrimSpace(tail) != "" {
+		return nil, fmt.Errorf("malformed payload in Probe %q", rest)
+	}
+	return &serviceProbe{protocol: protocol, name: fields[1], payload: unescapePayload(payload)}, nil
+}
+
+// parseMatchLine parses "<service> m|regex|[flags] [p/../] [v/../] ..."
+func parseMatchLine(rest string) (serviceMatch, error) {
+	service, rest, _ := strings.Cut(rest, " ")
+	pattern, rest, err := cutDelimited(rest, 'm')
+	if err != nil {
+		return serviceMatch{}, fmt.Errorf("service %s: %v", service, err)
+	}
+
+	flags, rest, _ := strings.Cut(rest, " ")
+	prefix := ""
+	for _, flag := range flags {
+		if flag != 'i' && flag != 's' {
+			return serviceMatch{}, fmt.Errorf("service %s: unknown pattern flag %q", service, flag)
+		}
+		prefix += string(flag)
+	}
+	if prefix != "" {
+		prefix = "(?" + prefix + ")"
+	}
+	compiled, err := regexp.Compile(prefix + pattern)
+	if err != nil {
+		return serviceMatch{}, fmt.Errorf("service %s: %v", service, err)
+	}
+
+	match := serviceMatch{service: service, pattern: compiled}
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		field := rest[0]
+		var value string
+		if value, rest, err = cutDelimited(rest, field); err != nil {
+			return serviceMatch{}, fmt.Errorf("service %s: %v", service, err)
+		}
+		switch field {
+		case 'p':
+			match.product = value
+		case 'v':
+			match.version = value
+		case 'i':
+			match.info = value
+		case 'o':
+			match.osType = value
+		case 'h':
+			match.hostname = value
+		default:
+			return serviceMatch{}, fmt.Errorf("service %s: unknown template %q", service, field)
+		}
+	}
+	return match, nil
+}
+
+// cutDelimited parses "<prefix><d>value<d>" where d is any character and
+// returns value and the text after the closing delimiter
+func cutDelimited(text string, prefix byte) (value, rest string, err error) {
+	if len(text) < 3 || text[0] != prefix {
+		return "", "", fmt.Errorf("expected %c<delimiter>...<delimiter> in %q", prefix, text)
+	}
+	delimiter := text[1]
+	end := strings.IndexByte(text[2:], delimiter)
+	if end < 0 {
+		return "", "", fmt.Errorf("unterminated %c%c in %q", prefix, delimiter, text)
+	}
+	return text[2 : 2+end], text[3+end:], nil
+}
+
+// unescapePayload decodes the \r \n \t \0 \\ and \xHH escapes of a payload
+func unescapePayload(text string) []byte {
+	var payload []byte
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' || i+1 == len(text) {
+			payload = append(payload, text[i])
+			continue
+		}
+		i++
+		switch text[i] {
+		case 'r':
+			payload = append(payload, '\r')
+		case 'n':
+			payload = append(payload, '\n')
+		case 't':
+			payload = append(payload, '\t')
+		case '0':
+			payload = append(payload, 0)
+		case 'x':
+			if i+2 < len(text) {
+				if value, err := strconv.ParseUint(text[i+1:i+3], 16, 8); err == nil {
+					payload = append(payload, byte(value))
+					i += 2
+					continue
+				}
+			}
+			payload = append(payload, 'x')
+		default:
+			payload = append(payload, text[i])
+		}
+	}
+	return payload
+}
+
+// latin1 maps each byte to the rune of the same value, so patterns match
+// binary replies byte for byte
+func latin1(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// match returns the first hard match of the probe's signatures, or its
+// first softmatch when no hard match applies
+func (p *serviceProbe) match(response []byte) (serviceInfo, bool) {
+	if p == nil || len(response) == 0 {
+		return serviceInfo{}, false
+	}
+
+	text := latin1(response)
+	var soft *serviceInfo
+	for _, m := range p.matches {
+		groups := m.pattern.FindStringSubmatch(text)
+		if groups == nil {
+			continue
+		}
+		if m.soft {
+			if soft == nil {
+				soft = &serviceInfo{service: m.service, soft: true}
+			}
+			continue
+		}
+		return serviceInfo{
+			service:   m.service,
+			product:   expandTemplate(m.product, groups),
+			version:   expandTemplate(m.version, groups),
+			extraInfo: expandTemplate(m.info, groups),
+			osType:    expandTemplate(m.osType, groups),
+			hostname:  expandTemplate(m.hostname, groups),
+		}, true
+	}
+	if soft != nil {
+		return *soft, true
+	}
+	return serviceInfo{}, false
+}
+
+// expandTemplate substitutes $1 to $9 with the capture groups
+func expandTemplate(template string, groups []string) string {
+	var expanded strings.Builder
+	for i := 0; i < len(template); i++ {
+		if template[i] == '$' && i+1 < len(template) && template[i+1] >= '1' && template[i+1] <= '9' {
+			if group := int(template[i+1] - '0'); group < len(groups) {
+				expanded.WriteString(groups[group])
+			}
+			i++
+			continue
+		}
+		expanded.WriteByte(template[i])
+	}
+	return strings.TrimSpace(expanded.String())
+}
+
+// probesFor returns the active probes of a protocol to send to a port:
+// the probes targeting it first, then the fallback probes
+func probesFor(protocol string, port int) []*serviceProbe {
+	probes, err := serviceProbes()
+	if err != nil {
+		return nil
+	}
+
+	var targeted, fallback []*serviceProbe
+	for _, probe := range probes {
+		switch {
+		case probe.protocol != protocol || len(probe.payload) == 0:
+		case probe.ports[port]:
+			targeted = append(targeted, probe)
+		case probe.fallback:
+			fallback = append(fallback, probe)
+		}
+	}
+	return append(targeted, fallback...)
+}
+
+// nullProbe returns the TCP probe matching banners sent on connect
+func nullProbe() *serviceProbe {
+	probes, _ := serviceProbes()
+	for _, probe := range probes {
+		if probe.protocol == ProtocolTCP && len(probe.payload) == 0 {
+			return probe
+		}
+	}
+	return &serviceProbe{protocol: ProtocolTCP, name: "NULL"}
+}
+
+// applyServiceInfo records a match on a port result. Soft matches only
+// replace the service name.
+func applyServiceInfo(result *PortResult, info serviceInfo) {
+	result.Service = info.service
+	if info.soft {
+		return
+	}
+	result.Product = info.product
+	result.Version = info.version
+	result.ExtraInfo = info.extraInfo
+	result.OSType = info.osType
+}
+
+// ServiceProber connects like ConnectProber and identifies the service
+// nmap -sV style: it reads the banner sent on connect, then sends the
+// protocol-specific probes of the signature database (HTTP GET, Redis INFO,
+// SMB negotiate, ...) until a signature matches, filling Product, Version
+// and ExtraInfo. Ports silent on connect are tried with a TLS handshake;
+// when it succeeds the certificate is recorded in TLS and the probes are
+// sent through the TLS tunnel. Web servers are then fingerprinted in HTTP
+// and SSH servers in SSH.
+type ServiceProber struct {
+	Timeout time.Duration
+}
+
+// Protocol returns "tcp"
+func (p ServiceProber) Protocol() string { return "tcp" }
+
+// Probe checks the port and identifies the service listening on it
+func (p ServiceProber) Probe(ctx context.Context, ip string, port int) PortResult {
+	result, conn := dialTCP(ctx, ip, port, p.Timeout)
+	if conn == nil {
+		return result
+	}
+
+	// NULL probe: many services greet first
+	response := exchange(ctx, conn, nil, p.Timeout, nullProbe())
+	conn.Close()
+	result.Banner = probeBanner(response)
+
+	address := net.JoinHostPort(ip, strconv.Itoa(port))
+	dial := func() (net.Conn, error) {
+		dialer := net.Dialer{Timeout: p.Timeout}
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+
+	if len(response) == 0 {
+		if tunnel, info := p.tlsTunnel(ctx, dial); tunnel != nil {
+			result.TLS = info
+			dial = tunnel
+			// Greeting sent once the TLS session is up (IMAPS, POP3S, ...)
+			if conn, err := dial(); err == nil {
+				response = exchange(ctx, conn, nil, p.Timeout, nullProbe())
+				conn.Close()
+				result.Banner = probeBanner(response)
+			}
+		}
+	}
+	p.identify(ctx, &result, response, dial)
+
+	switch {
+	case ctx.Err() != nil:
+	case isHTTPService(result.Service):
+		result.HTTP, _ = fingerprintHTTP(ctx, address, dial, p.Timeout)
+	case result.Service == "ssh":
+		if result.SSH, _ = fingerprintSSH(ctx, dial, p.Timeout); result.SSH != nil {
+			// The server's KEXINIT may have been read with the banner
+			result.Banner = result.SSH.Banner
+		}
+	}
+	return result
+}
+
+// identify matches the NULL probe response, then sends the active probes
+// over connections opened with dial until a signature matches
+func (p ServiceProber) identify(ctx context.Context, result *PortResult, response []byte, dial func() (net.Conn, error)) {
+	if info, ok := nullProbe().match(response); ok {
+		applyServiceInfo(result, info)
+		if !info.soft {
+			return
+		}
+	}
+
+	for i, probe := range probesFor(ProtocolTCP, result.Port) {
+		if i == maxActiveProbes || ctx.Err() != nil {
+			break
+		}
+		conn, err := dial()
+		if err != nil {
+			break
+		}
+		response := exchange(ctx, conn, probe.payload, p.Timeout, probe)
+		conn.Close()
+
+		if result.Banner == "" {
+			result.Banner = probeBanner(response)
+		}
+		if info, ok := probe.match(response); ok {
+			applyServiceInfo(result, info)
+			if !info.soft {
+				return
+			}
+		}
+	}
+
+	// No signature: fall back to the banner heuristics
+	if result.Banner != "" && result.Product == "" {
+		result.Version = extractVersionFromBanner(result.Banner)
+		if result.Service == ServiceName("tcp", result.Port) {
+			result.Service = identifyServiceByBanner(result.Banner, result.Service)
+		}
+	}
+}
+
+// tlsTunnel tries a TLS handshake on a new connection. When the port speaks
+// TLS it returns the certificate description and a dial function opening
+// TLS connections to the port.
+func (p ServiceProber) tlsTunnel(ctx context.Context, dial func() (net.Conn, error)) (func() (net.Conn, error), *TLSInfo) {
+	conn, err := dial()
+	if err != nil {
+		return nil, nil
+	}
+	tlsConn, info, err := tlsHandshake(ctx, conn, p.Timeout)
+	if err != nil {
+		return nil, nil
+	}
+	tlsConn.Close()
+
+	return func() (net.Conn, error) {
+		conn, err := dial()
+		if err != nil {
+			return nil, err
+		}
+		tlsConn, _, err := tlsHandshake(ctx, conn, p.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		return tlsConn, nil
+	}, info
+}
+
+// exchange sends payload (when not empty) and reads the reply until the
+// probe has a hard match, the peer closes the connection, the timeout
+// expires or maxProbeResponse bytes arrived
+func exchange(ctx context.Context, conn net.Conn, payload []byte, timeout time.Duration, probe *serviceProbe) []byte {
+	// Unblock the read if the scan is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	if len(payload) > 0 {
+		if _, err := conn.Write(payload); err != nil {
+			return nil
+		}
+	}
+
+	var response []byte
+	buffer := make([]byte, 4096)
+	for len(response) < maxProbeResponse {
+		n, err := conn.Read(buffer)
+		response = append(response, buffer[:n]...)
+		if err != nil {
+			break
+		}
+		if info, ok := probe.match(response); ok && !info.soft {
+			break
+		}
+	}
+	return response
+}
+
+// probeBanner returns the printable text of a reply, capped to keep the
+// reports readable
+func probeBanner(response []byte) string {
+	if len(response) > 512 {
+		response = response[:512]
+	}
+	return printableBanner(response)
+}
@@ -0,0 +1,177 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmbeddedServiceProbes(t *testing.T) {
+	probes, err := serviceProbes()
+	if err != nil {
+		t.Fatalf("embedded service probes: %v", err)
+	}
+	if len(probes) == 0 || nullProbe().name != "NULL" {
+		t.Fatalf("got %d probes, want a NULL probe first", len(probes))
+	}
+
+	udp := probesFor(ProtocolUDP, 53)
+	if len(udp) == 0 || udp[0].name != "DNSVersionBindReq" {
+		t.Errorf("probesFor(udp, 53) does not start with DNSVersionBindReq")
+	}
+	tcp := probesFor(ProtocolTCP, 6379)
+	if len(tcp) == 0 || tcp[0].name != "RedisInfo" || tcp[len(tcp)-1].name != "GetRequest" {
+		t.Errorf("probesFor(tcp, 6379) = %d probes, want RedisInfo first and the GetRequest fallback last", len(tcp))
+	}
+}
+
+func TestParseServiceProbesErrors(t *testing.T) {
+	tests := []string{
+		"match ssh m|^SSH|",
+		"Probe SCTP x q||",
+		"Probe TCP x q|abc",
+		"Probe TCP x q||\nmatch ssh m|(|",
+		"Probe TCP x q||\nmatch ssh m|x|z",
+		"Probe TCP x q||\nmatch ssh m|x| k/y/",
+		"Probe TCP x q||\nports 99999",
+		"Probe TCP x q||\nrarity 5",
+	}
+	for _, data := range tests {
+		if _, err := parseServiceProbes(data); err == nil {
+			t.Errorf("parseServiceProbes(%q) succeeded, want an error", data)
+		}
+	}
+}
+
+func TestUnescapePayload(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`GET / HTTP/1.0\r\n\r\n`, "GET / HTTP/1.0\r\n\r\n"},
+		{`\x00\x01\xff`, "\x00\x01\xff"},
+		{`a\0b\tc\\d`, "a\x00b\tc\\d"},
+		{`\xZZ`, "xZZ"},
+		{`trailing\`, `trailing\`},
+	}
+	for _, tt := range tests {
+		if got := string(unescapePayload(tt.in)); got != tt.want {
+			t.Errorf("unescapePayload(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	groups := []string{"SSH-2.0-OpenSSH_9.6", "2.0", "9.6"}
+	if got := expandTemplate("OpenSSH $2 (protocol $1)", groups); got != "OpenSSH 9.6 (protocol 2.0)" {
+		t.Errorf("expandTemplate = %q", got)
+	}
+	if got := expandTemplate("v$5 $", groups); got != "v $" {
+		t.Errorf("expandTemplate with a missing group = %q, want %q", got, "v $")
+	}
+}
+
+func TestServiceProbeMatch(t *testing.T) {
+	probes, err := serviceProbes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]*serviceProbe)
+	for _, probe := range probes {
+		byName[probe.protocol+"/"+probe.name] = probe
+	}
+
+	tests := []struct {
+		probe    string
+		response string
+		want     serviceInfo
+	}{
+		{"tcp/NULL", "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13.5\r\n",
+			serviceInfo{service: "ssh", product: "OpenSSH", version: "9.6p1", extraInfo: "Ubuntu 3ubuntu13.5; protocol 2.0", osType: "Linux"}},
+		{"tcp/NULL", "SSH-2.0-dropbear_2022.83\r\n",
+			serviceInfo{service: "ssh", product: "Dropbear sshd", version: "2022.83", extraInfo: "protocol 2.0"}},
+		{"tcp/NULL", "J\x00\x00\x00\x0a8.0.36-0ubuntu0.22.04.1\x00\x08\x00\x00\x00",
+			serviceInfo{service: "mysql", product: "MySQL", version: "8.0.36-0ubuntu0.22.04.1"}},
+		{"tcp/GetRequest", "HTTP/1.0 200 OK\r\nServer: SimpleHTTP/0.6 Python/3.12.3\r\n\r\n",
+			serviceInfo{service: "http", product: "SimpleHTTPServer", version: "0.6", extraInfo: "Python 3.12.3"}},
+		{"tcp/SMBNegotiate", "\x00\x00\x00\xf8\xfeSMB@\x00" + strings.Repeat("\x00", 62),
+			serviceInfo{service: "microsoft-ds", product: "SMB", extraInfo: "SMB2"}},
+		{"tcp/RedisInfo", "$3000\r\n# Server\r\nredis_version:7.2.4\r\n",
+			serviceInfo{service: "redis", product: "Redis key-value store", version: "7.2.4"}},
+	}
+	for _, tt := range tests {
+		got, ok := byName[tt.probe].match([]byte(tt.response))
+		if !ok || got != tt.want {
+			t.Errorf("%s match(%q) = %+v, %v; want %+v", tt.probe, tt.response, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := byName["tcp/NULL"].match([]byte("nothing known here")); ok {
+		t.Error("NULL probe matched an unknown banner")
+	}
+	var missing *serviceProbe
+	if _, ok := missing.match([]byte("SSH-2.0-x")); ok {
+		t.Error("a nil probe matched")
+	}
+}
+
+// serveTCP accepts loopback connections and hands each one to handle
+func serveTCP(t *testing.T, handle func(net.Conn)) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestServiceProberBanner(t *testing.T) {
+	port := serveTCP(t, func(conn net.Conn) {
+		conn.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	result := ServiceProber{Timeout: time.Second}.Probe(context.Background(), "127.0.0.1", port)
+	if !result.IsOpen || result.Service != "ssh" || result.Product != "OpenSSH" || result.Version != "9.6" {
+		t.Fatalf("got %+v, want an open OpenSSH 9.6", result)
+	}
+	if got := versionString(result); got != "OpenSSH 9.6 (protocol 2.0)" {
+		t.Errorf("versionString = %q", got)
+	}
+}
+
+func TestServiceProberActiveProbe(t *testing.T) {
+	// Silent until it receives a request, like an HTTP server
+	port := serveTCP(t, func(conn net.Conn) {
+		conn.SetDeadline(time.Now().Add(2 * time.Second))
+		request, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil || !strings.HasPrefix(request, "GET / ") {
+			return
+		}
+		conn.Write([]byte("HTTP/1.0 200 OK\r\nServer: nginx/1.24.0\r\nContent-Length: 0\r\n\r\n"))
+	})
+
+	result := ServiceProber{Timeout: 300 * time.Millisecond}.Probe(context.Background(), "127.0.0.1", port)
+	if !result.IsOpen || result.Service != "http" || result.Product != "nginx" || result.Version != "1.24.0" {
+		t.Fatalf("got %+v, want an open nginx 1.24.0", result)
+	}
+	if !strings.HasPrefix(result.Banner, "HTTP/1.0 200 OK") {
+		t.Errorf("banner = %q, want the HTTP reply", result.Banner)
+	}
+}
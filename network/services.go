@@ -0,0 +1,76 @@
+package network
+
+import (
+	"bufio"
+	_ "embed"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:generate go run data/gen_services.go -in service-names-port-numbers.csv -out data/services.txt
+
+// serviceRegistryData is the embedded port/protocol service registry
+//
+//go:embed data/services.txt
+var serviceRegistryData string
+
+// ServiceEntry is a service registered for a port and transport protocol
+type ServiceEntry struct {
+	Name        string `json:"name"`
+	Port        int    `json:"port"`
+	Protocol    string `json:"protocol"` // "tcp" or "udp"
+	Description string `json:"description,omitempty"`
+}
+
+// serviceRegistry maps "port/protocol" to its service, loaded on first use
+var serviceRegistry = sync.OnceValue(func() map[string]ServiceEntry {
+	return parseServiceRegistry(serviceRegistryData)
+})
+
+// parseServiceRegistry parses lines of the form
+// "<service> <port>/<protocol> [# description]". The first entry of a
+// port/protocol wins.
+func parseServiceRegistry(data string) map[string]ServiceEntry {
+	services := make(map[string]ServiceEntry)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line, description, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		number, protocol, found := strings.Cut(fields[1], "/")
+		port, err := strconv.Atoi(number)
+		if !found || err != nil {
+			continue
+		}
+		if _, exists := services[fields[1]]; exists {
+			continue
+		}
+		services[fields[1]] = ServiceEntry{
+			Name:        fields[0],
+			Port:        port,
+			Protocol:    protocol,
+			Description: strings.TrimSpace(description),
+		}
+	}
+	return services
+}
+
+// LookupService returns the service registered for a port. The protocol is
+// "tcp" or "udp"; the "tcp6" and "udp6" socket types are accepted too.
+func LookupService(protocol string, port int) (ServiceEntry, bool) {
+	protocol = strings.TrimSuffix(strings.ToLower(protocol), "6")
+	service, exists := serviceRegistry()[strconv.Itoa(port)+"/"+protocol]
+	return service, exists
+}
+
+// ServiceName returns the name of the service registered for a port, or
+// "Unknown" when the registry has none
+func ServiceName(protocol string, port int) string {
+	if service, exists := LookupService(protocol, port); exists {
+		return service.Name
+	}
+	return "Unknown"
+}
@@ -0,0 +1,39 @@
+package network
+
+import "testing"
+
+func TestParseServiceRegistry(t *testing.T) {
+	services := parseServiceRegistry("# comment\nssh\t22/tcp\t# Secure Shell\nssh-dup 22/tcp\nbad 23\nbad x/tcp\ndomain 53/udp\n")
+	if len(services) != 2 {
+		t.Fatalf("parseServiceRegistry = %v, want 2 entries", services)
+	}
+	want := ServiceEntry{Name: "ssh", Port: 22, Protocol: "tcp", Description: "Secure Shell"}
+	if services["22/tcp"] != want {
+		t.Errorf("22/tcp = %+v, want %+v", services["22/tcp"], want)
+	}
+	if services["53/udp"].Name != "domain" {
+		t.Errorf("53/udp = %+v, want domain", services["53/udp"])
+	}
+}
+
+func TestLookupService(t *testing.T) {
+	tests := []struct {
+		protocol string
+		port     int
+		want     string
+	}{
+		{"tcp", 22, "ssh"},
+		{"tcp6", 443, "https"},
+		{"udp", 53, "domain"},
+		{"UDP6", 161, "snmp"},
+		{"tcp", 3389, "ms-wbt-server"},
+		{"udp", 5353, "mdns"},
+		{"tcp", 5353, "Unknown"},
+		{"tcp", 65000, "Unknown"},
+	}
+	for _, tt := range tests {
+		if got := ServiceName(tt.protocol, tt.port); got != tt.want {
+			t.Errorf("ServiceName(%s, %d) = %q, want %q", tt.protocol, tt.port, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SSHInfo is the fingerprint of an SSH server: the algorithms it offers in
+// its key exchange init and its host key
+type SSHInfo struct {
+	Banner             string   `json:"banner"` // Identification line (SSH-2.0-...)
+	KexAlgorithms      []string `json:"kex_algorithms"`
+	HostKeyAlgorithms  []string `json:"host_key_algorithms"`
+	Ciphers            []string `json:"ciphers"`
+	MACs               []string `json:"macs"`
+	Compression        []string `json:"compression"`
+	HostKeyType        string   `json:"host_key_type,omitempty"` // e.g. ssh-ed25519
+	HostKeyBits        int      `json:"host_key_bits,omitempty"`
+	HostKeyFingerprint string   `json:"host_key_fingerprint,omitempty"` // SHA256:... as printed by ssh-keygen -l
+	WeakAlgorithms     []string `json:"weak_algorithms,omitempty"`
+}
+
+// SSH message numbers (RFC 4253, RFC 5656)
+const (
+	sshMsgKexInit      = 20
+	sshMsgKexECDHInit  = 30
+	sshMsgKexECDHReply = 31
+)
+
+// maxSSHPacket caps the size of the packets read from the server
+const maxSSHPacket = 64 * 1024
+
+// sshClientVersion is the identification line sent to servers
+const sshClientVersion = "SSH-2.0-network-toolkit"
+
+// sshKexCurves are the ECDH key exchanges used to obtain the host key, in
+// order of preference
+var sshKexCurves = []struct {
+	name  string
+	curve ecdh.Curve
+}{
+	{"curve25519-sha256", ecdh.X25519()},
+	{"curve25519-sha256@libssh.org", ecdh.X25519()},
+	{"ecdh-sha2-nistp256", ecdh.P256()},
+	{"ecdh-sha2-nistp384", ecdh.P384()},
+	{"ecdh-sha2-nistp521", ecdh.P521()},
+}
+
+// sshHostKeyAlgorithms are the host key types requested, strongest first
+var sshHostKeyAlgorithms = []string{
+	"ssh-ed25519",
+	"ecdsa-sha2-nistp256",
+	"ecdsa-sha2-nistp384",
+	"ecdsa-sha2-nistp521",
+	"rsa-sha2-512",
+	"rsa-sha2-256",
+	"ssh-rsa",
+	"ssh-dss",
+}
+
+// weakSSHAlgorithms are the algorithms flagged in hardening audits, by
+// exact name or by prefix when the name ends with "*"
+var weakSSHAlgorithms = []string{
+	// Key exchange: SHA-1 and 1024-bit groups
+	"diffie-hellman-group1-sha1",
+	"diffie-hellman-group14-sha1",
+	"diffie-hellman-group-exchange-sha1",
+	"gss-gex-sha1-*",
+	"gss-group1-sha1-*",
+	"gss-group14-sha1-*",
+	"rsa1024-sha1",
+	// Host keys: DSA and SHA-1 signatures
+	"ssh-dss",
+	"ssh-rsa",
+	// Ciphers: CBC modes and broken ciphers
+	"3des-cbc",
+	"aes128-cbc",
+	"aes192-cbc",
+	"aes256-cbc",
+	"blowfish-cbc",
+	"cast128-cbc",
+	"rijndael-cbc@lysator.liu.se",
+	"arcfour*",
+	"des*",
+	"none",
+	// MACs: MD5, SHA-1, truncated and 64-bit tags
+	"hmac-md5*",
+	"hmac-sha1*",
+	"hmac-ripemd160*",
+	"umac-64*",
+	"*-96",
+}
+
+// FingerprintSSH connects to the SSH server at ip:port, reads its key
+// exchange init and runs the key exchange far enough to receive its host
+// key. No authentication is attempted.
+func FingerprintSSH(ctx context.Context, ip string, port int, timeout time.Duration) (*SSHInfo, error) {
+	address := net.JoinHostPort(ip, strconv.Itoa(port))
+	return fingerprintSSH(ctx, func() (net.Conn, error) {
+		dialer := net.Dialer{Timeout: timeout}
+		return dialer.DialContext(ctx, "tcp", address)
+	}, timeout)
+}
+
+// fingerprintSSH fingerprints the SSH server reached with dial
+func fingerprintSSH(ctx context.Context, dial func() (net.Conn, error), timeout time.Duration) (*SSHInfo, error) {
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// Unblock the exchange if the scan is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	conn.SetDeadline(time.Now().Add(2 * timeout))
+
+	reader := bufio.NewReader(conn)
+	banner, err := readSSHVersion(reader)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(conn, "%s\r\n", sshClientVersion); err != nil {
+		return nil, err
+	}
+
+	payload, err := readSSHPacket(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading KEXINIT: %v", err)
+	}
+	kexInit, err := parseKexInit(payload)
+	if err != nil {
+		return nil, err
+	}
+	info := &SSHInfo{
+		Banner:            banner,
+		KexAlgorithms:     kexInit.kex,
+		HostKeyAlgorithms: kexInit.hostKey,
+		Ciphers:           mergeNameLists(kexInit.ciphersClient, kexInit.ciphersServer),
+		MACs:              mergeNameLists(kexInit.macsClient, kexInit.macsServer),
+		Compression:       mergeNameLists(kexInit.compressionClient, kexInit.compressionServer),
+	}
+	info.WeakAlgorithms = weakAlgorithms(info)
+
+	// The host key comes with the server's key exchange reply. Failing to
+	// get it still leaves the algorithm lists.
+	if hostKey, err := sshHostKey(conn, reader, kexInit); err == nil {
+		info.HostKeyType, info.HostKeyBits = parseHostKey(hostKey)
+		info.HostKeyFingerprint = SSHFingerprint(hostKey)
+		if info.HostKeyType == "ssh-rsa" && info.HostKeyBits > 0 && info.HostKeyBits < minRSABits {
+			info.WeakAlgorithms = append(info.WeakAlgorithms, fmt.Sprintf("RSA host key %d bits", info.HostKeyBits))
+		}
+	}
+	return info, nil
+}
+
+// readSSHVersion returns the server identification line, skipping the
+// lines servers may send before it (RFC 4253 section 4.2)
+func readSSHVersion(reader *bufio.Reader) (string, error) {
+	for i := 0; i < 32; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("reading SSH identification: %v", err)
+		}
+		if line = strings.TrimRight(line, "\r\n"); strings.HasPrefix(line, "SSH-") {
+			return line, nil
+		}
+	}
+	return "", errors.New("no SSH identification line")
+}
+
+// kexInit holds the name-lists of an SSH_MSG_KEXINIT
+type kexInit struct {
+	kex, hostKey                         []string
+	ciphersClient, ciphersServer         []string
+	macsClient, macsServer               []string
+	compressionClient, compressionServer []string
+}
+
+// parseKexInit parses an SSH_MSG_KEXINIT payload (RFC 4253 section 7.1)
+func parseKexInit(payload []byte) (kexInit, error) {
+	if len(payload) < 17 || payload[0] != sshMsgKexInit {
+		return kexInit{}, errors.New("not an SSH KEXINIT message")
+	}
+	data := payload[17:] // Message number and cookie
+
+	lists := make([][]string, 10)
+	for i := range lists {
+		var value []byte
+		var ok bool
+		if value, data, ok = readSSHString(data); !ok {
+			return kexInit{}, errors.New("truncated SSH KEXINIT message")
+		}
+		if len(value) > 0 {
+			lists[i] = strings.Split(string(value), ",")
+		}
+	}
+	return kexInit{
+		kex: lists[0], hostKey: lists[1],
+		ciphersClient: lists[2], ciphersServer: lists[3],
+		macsClient: lists[4], macsServer: lists[5],
+		compressionClient: lists[6], compressionServer: lists[7],
+	}, nil
+}
+
+// sshHostKey sends our KEXINIT and an ECDH init and returns the host key
+// blob of the server's reply
+func sshHostKey(conn net.Conn, reader *bufio.Reader, server kexInit) ([]byte, error) {
+	var curve ecdh.Curve
+	var kexName string
+	for _, candidate := range sshKexCurves {
+		if containsName(server.kex, candidate.name) {
+			curve, kexName = candidate.curve, candidate.name
+			break
+		}
+	}
+	if curve == nil {
+		return nil, errors.New("no supported ECDH key exchange")
+	}
+	var hostKeys []string
+	for _, name := range sshHostKeyAlgorithms {
+		if containsName(server.hostKey, name) {
+			hostKeys = append(hostKeys, name)
+		}
+	}
+	if len(hostKeys) == 0 {
+		return nil, errors.New("no supported host key algorithm")
+	}
+
+	// Echo the server's ciphers and MACs: the exchange stops before they
+	// are used
+	cookie := make([]byte, 16)
+	rand.Read(cookie)
+	message := append([]byte{sshMsgKexInit}, cookie...)
+	for _, list := range [][]string{
+		{kexName}, hostKeys,
+		server.ciphersClient, server.ciphersServer,
+		server.macsClient, server.macsServer,
+		{"none"}, {"none"}, nil, nil,
+	} {
+		message = appendSSHString(message, []byte(strings.Join(list, ",")))
+	}
+	message = append(message, 0, 0, 0, 0, 0) // first_kex_packet_follows, reserved
+	if err := writeSSHPacket(conn, message); err != nil {
+		return nil, err
+	}
+
+	key, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	init := appendSSHString([]byte{sshMsgKexECDHInit}, key.PublicKey().Bytes())
+	if err := writeSSHPacket(conn, init); err != nil {
+		return nil, err
+	}
+
+	reply, err := readSSHPacket(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading KEX reply: %v", err)
+	}
+	if len(reply) == 0 || reply[0] != sshMsgKexECDHReply {
+		return nil, errors.New("unexpected SSH message instead of the KEX reply")
+	}
+	hostKey, _, ok := readSSHString(reply[1:])
+	if !ok {
+		return nil, errors.New("truncated SSH KEX reply")
+	}
+	return hostKey, nil
+}
+
+// parseHostKey returns the type of a host key blob and its size in bits
+// when it is known
+func parseHostKey(blob []byte) (string, int) {
+	name, rest, ok := readSSHString(blob)
+	if !ok {
+		return "", 0
+	}
+	switch keyType := string(name); {
+	case keyType == "ssh-ed25519":
+		return keyType, 256
+	case keyType == "ssh-rsa":
+		_, rest, _ = readSSHString(rest) // e
+		if n, _, ok := readSSHString(rest); ok {
+			return keyType, new(big.Int).SetBytes(n).BitLen()
+		}
+		return keyType, 0
+	case keyType == "ssh-dss":
+		if p, _, ok := readSSHString(rest); ok {
+			return keyType, new(big.Int).SetBytes(p).BitLen()
+		}
+		return keyType, 0
+	case strings.HasPrefix(keyType, "ecdsa-sha2-nistp"):
+		bits, _ := strconv.Atoi(strings.TrimPrefix(keyType, "ecdsa-sha2-nistp"))
+		return keyType, bits
+	default:
+		return keyType, 0
+	}
+}
+
+// SSHFingerprint returns the SHA-256 fingerprint of a host key blob in the
+// format of ssh-keygen -l ("SHA256:" and unpadded base64)
+func SSHFingerprint(hostKey []byte) string {
+	sum := sha256.Sum256(hostKey)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// weakAlgorithms lists the weak algorithms offered by a server
+func weakAlgorithms(info *SSHInfo) []string {
+	var weak []string
+	for _, list := range [][]string{info.KexAlgorithms, info.HostKeyAlgorithms, info.Ciphers, info.MACs} {
+		for _, name := range list {
+			if isWeakSSHAlgorithm(name) {
+				weak = append(weak, name)
+			}
+		}
+	}
+	return weak
+}
+
+// isWeakSSHAlgorithm matches an algorithm name against weakSSHAlgorithms
+func isWeakSSHAlgorithm(name string) bool {
+	for _, pattern := range weakSSHAlgorithms {
+		switch {
+		case strings.HasPrefix(pattern, "*"):
+			if strings.HasSuffix(name, pattern[1:]) {
+				return true
+			}
+		case strings.HasSuffix(pattern, "*"):
+			if strings.HasPrefix(name, pattern[:len(pattern)-1]) {
+				return true
+			}
+		case name == pattern:
+			return true
+		}
+	}
+	return false
+}
+
+// readSSHPacket reads an unencrypted binary packet and returns its payload
+// (RFC 4253 section 6)
+func readSSHPacket(reader io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	padding := uint32(header[4])
+	if length < padding+1 || length > maxSSHPacket {
+		return nil, fmt.Errorf("invalid SSH packet length %d", length)
+	}
+	packet := make([]byte, length-1)
+	if _, err := io.ReadFull(reader, packet); err != nil {
+		return nil, err
+	}
+	return packet[:len(packet)-int(padding)], nil
+}
+
+// writeSSHPacket writes payload as an unencrypted binary packet padded to
+// a multiple of 8 bytes
+func writeSSHPacket(w io.Writer, payload []byte) error {
+	padding := 8 - (len(payload)+5)%8
+	if padding < 4 {
+		padding += 8
+	}
+	packet := binary.BigEndian.AppendUint32(nil, uint32(len(payload)+padding+1))
+	packet = append(packet, byte(padding))
+	packet = append(packet, payload...)
+	packet = append(packet, make([]byte, padding)...)
+	_, err := w.Write(packet)
+	return err
+}
+
+// readSSHString reads a uint32-length-prefixed string
+func readSSHString(data []byte) (value, rest []byte, ok bool) {
+	if len(data) < 4 {
+		return nil, nil, false
+	}
+	length := binary.BigEndian.Uint32(data)
+	if uint32(len(data)-4) < length {
+		return nil, nil, false
+	}
+	return data[4 : 4+length], data[4+length:], true
+}
+
+// appendSSHString appends a uint32-length-prefixed string
+func appendSSHString(data, value []byte) []byte {
+	data = binary.BigEndian.AppendUint32(data, uint32(len(value)))
+	return append(data, value...)
+}
+
+// mergeNameLists joins the client-to-server and server-to-client lists of
+// a KEXINIT without duplicates
+func mergeNameLists(a, b []string) []string {
+	merged := append([]string(nil), a...)
+	for _, name := range b {
+		if !containsName(merged, name) {
+			merged = append(merged, name)
+		}
+	}
+	return merged
+}
+
+// containsName reports whether list contains name
+func containsName(list []string, name string) bool {
+	for _, item := range list {
+		if item == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sshSummary is the one-line description of an SSH server shown by the
+// text reports
+func sshSummary(s *SSHInfo) string {
+	summary := fmt.Sprintf("%d kex, %d ciphers, %d MACs", len(s.KexAlgorithms), len(s.Ciphers), len(s.MACs))
+	if s.HostKeyFingerprint != "" {
+		summary = fmt.Sprintf("%s (%d) %s, %s", s.HostKeyType, s.HostKeyBits, s.HostKeyFingerprint, summary)
+	}
+	if len(s.WeakAlgorithms) > 0 {
+		summary += " ⚠️  weak: " + strings.Join(s.WeakAlgorithms, ", ")
+	}
+	return summary
+}
@@ -0,0 +1,178 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testKexInit builds a server SSH_MSG_KEXINIT with the same lists in both
+// directions
+func testKexInit(kex, hostKeys, ciphers, macs []string) []byte {
+	message := append([]byte{sshMsgKexInit}, make([]byte, 16)...)
+	for _, list := range [][]string{kex, hostKeys, ciphers, ciphers, macs, macs, {"none", "zlib@openssh.com"}, {"none", "zlib@openssh.com"}, nil, nil} {
+		message = appendSSHString(message, []byte(strings.Join(list, ",")))
+	}
+	return append(message, 0, 0, 0, 0, 0)
+}
+
+// serveSSH runs a fake SSH server that sends its identification and
+// KEXINIT on connect, like OpenSSH, and answers an ECDH init with hostKey
+func serveSSH(t *testing.T, kexInit, hostKey []byte) int {
+	return serveTCP(t, func(conn net.Conn) {
+		conn.SetDeadline(time.Now().Add(2 * time.Second))
+		conn.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+		if writeSSHPacket(conn, kexInit) != nil {
+			return
+		}
+
+		reader := bufio.NewReader(conn)
+		if line, err := reader.ReadString('\n'); err != nil || !strings.HasPrefix(line, "SSH-2.0-") {
+			return
+		}
+		if payload, err := readSSHPacket(reader); err != nil || payload[0] != sshMsgKexInit {
+			return
+		}
+		if payload, err := readSSHPacket(reader); err != nil || payload[0] != sshMsgKexECDHInit {
+			return
+		}
+		reply := appendSSHString([]byte{sshMsgKexECDHReply}, hostKey)
+		reply = appendSSHString(reply, make([]byte, 32))
+		reply = appendSSHString(reply, []byte("signature"))
+		writeSSHPacket(conn, reply)
+	})
+}
+
+// ed25519HostKey returns the SSH blob of a new Ed25519 key
+func ed25519HostKey(t *testing.T) []byte {
+	t.Helper()
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return appendSSHString(appendSSHString(nil, []byte("ssh-ed25519")), public)
+}
+
+func TestFingerprintSSH(t *testing.T) {
+	hostKey := ed25519HostKey(t)
+	port := serveSSH(t, testKexInit(
+		[]string{"curve25519-sha256", "diffie-hellman-group14-sha256", "diffie-hellman-group1-sha1"},
+		[]string{"rsa-sha2-512", "ssh-ed25519"},
+		[]string{"chacha20-poly1305@openssh.com", "aes256-ctr", "aes128-cbc"},
+		[]string{"hmac-sha2-256-etm@openssh.com", "hmac-sha1", "umac-64-etm@openssh.com", "hmac-sha2-256-96"},
+	), hostKey)
+
+	info, err := FingerprintSSH(context.Background(), "127.0.0.1", port, time.Second)
+	if err != nil {
+		t.Fatalf("FingerprintSSH: %v", err)
+	}
+	if info.Banner != "SSH-2.0-OpenSSH_9.6" {
+		t.Errorf("banner = %q", info.Banner)
+	}
+	if want := []string{"chacha20-poly1305@openssh.com", "aes256-ctr", "aes128-cbc"}; !reflect.DeepEqual(info.Ciphers, want) {
+		t.Errorf("ciphers = %v, want %v", info.Ciphers, want)
+	}
+	if want := []string{"none", "zlib@openssh.com"}; !reflect.DeepEqual(info.Compression, want) {
+		t.Errorf("compression = %v, want %v", info.Compression, want)
+	}
+	sum := sha256.Sum256(hostKey)
+	if info.HostKeyType != "ssh-ed25519" || info.HostKeyBits != 256 ||
+		info.HostKeyFingerprint != "SHA256:"+base64.RawStdEncoding.EncodeToString(sum[:]) {
+		t.Errorf("host key %s %d %s", info.HostKeyType, info.HostKeyBits, info.HostKeyFingerprint)
+	}
+	want := []string{"diffie-hellman-group1-sha1", "aes128-cbc", "hmac-sha1", "umac-64-etm@openssh.com", "hmac-sha2-256-96"}
+	if !reflect.DeepEqual(info.WeakAlgorithms, want) {
+		t.Errorf("weak algorithms = %v, want %v", info.WeakAlgorithms, want)
+	}
+}
+
+func TestFingerprintSSHWeakRSAKey(t *testing.T) {
+	modulus := new(big.Int).Lsh(big.NewInt(1), 1023).Bytes()
+	hostKey := appendSSHString(nil, []byte("ssh-rsa"))
+	hostKey = appendSSHString(hostKey, []byte{1, 0, 1})
+	hostKey = appendSSHString(hostKey, append([]byte{0}, modulus...))
+	port := serveSSH(t, testKexInit(
+		[]string{"ecdh-sha2-nistp256"}, []string{"ssh-rsa"}, []string{"aes128-ctr"}, []string{"hmac-sha2-256"},
+	), hostKey)
+
+	info, err := FingerprintSSH(context.Background(), "127.0.0.1", port, time.Second)
+	if err != nil {
+		t.Fatalf("FingerprintSSH: %v", err)
+	}
+	if info.HostKeyType != "ssh-rsa" || info.HostKeyBits != 1024 {
+		t.Errorf("host key %s %d, want ssh-rsa 1024", info.HostKeyType, info.HostKeyBits)
+	}
+	if want := []string{"ssh-rsa", "RSA host key 1024 bits"}; !reflect.DeepEqual(info.WeakAlgorithms, want) {
+		t.Errorf("weak algorithms = %v, want %v", info.WeakAlgorithms, want)
+	}
+}
+
+func TestFingerprintSSHWithoutHostKey(t *testing.T) {
+	// Only legacy key exchanges: the algorithm lists are still reported
+	port := serveSSH(t, testKexInit(
+		[]string{"diffie-hellman-group14-sha1"}, []string{"ssh-rsa"}, []string{"aes128-ctr"}, []string{"hmac-sha2-256"},
+	), nil)
+
+	info, err := FingerprintSSH(context.Background(), "127.0.0.1", port, time.Second)
+	if err != nil {
+		t.Fatalf("FingerprintSSH: %v", err)
+	}
+	if info.HostKeyFingerprint != "" || !reflect.DeepEqual(info.KexAlgorithms, []string{"diffie-hellman-group14-sha1"}) {
+		t.Errorf("got %+v", info)
+	}
+	if summary := sshSummary(info); summary != "1 kex, 1 ciphers, 1 MACs ⚠️  weak: diffie-hellman-group14-sha1, ssh-rsa" {
+		t.Errorf("sshSummary = %q", summary)
+	}
+}
+
+func TestParseKexInitErrors(t *testing.T) {
+	valid := testKexInit([]string{"a"}, []string{"b"}, []string{"c"}, []string{"d"})
+	for _, payload := range [][]byte{nil, {sshMsgKexECDHInit}, valid[:len(valid)-20]} {
+		if _, err := parseKexInit(payload); err == nil {
+			t.Errorf("parseKexInit(%q) succeeded, want an error", payload)
+		}
+	}
+}
+
+func TestSSHPacketRoundTrip(t *testing.T) {
+	for size := 0; size < 20; size++ {
+		payload := []byte(strings.Repeat("x", size))
+		var buffer strings.Builder
+		if err := writeSSHPacket(&buffer, payload); err != nil {
+			t.Fatal(err)
+		}
+		if buffer.Len()%8 != 0 {
+			t.Errorf("packet of a %d-byte payload is %d bytes, not a multiple of 8", size, buffer.Len())
+		}
+		got, err := readSSHPacket(strings.NewReader(buffer.String()))
+		if err != nil || string(got) != string(payload) {
+			t.Errorf("round trip of %q = %q, %v", payload, got, err)
+		}
+	}
+}
+
+func TestServiceProberSSH(t *testing.T) {
+	port := serveSSH(t, testKexInit(
+		[]string{"curve25519-sha256"}, []string{"ssh-ed25519"}, []string{"aes256-gcm@openssh.com"}, []string{"hmac-sha2-512"},
+	), ed25519HostKey(t))
+
+	result := ServiceProber{Timeout: 300 * time.Millisecond}.Probe(context.Background(), "127.0.0.1", port)
+	if result.Service != "ssh" || result.Product != "OpenSSH" || result.SSH == nil {
+		t.Fatalf("got service %q product %q SSH %+v, want an SSH fingerprint", result.Service, result.Product, result.SSH)
+	}
+	if result.Banner != "SSH-2.0-OpenSSH_9.6" {
+		t.Errorf("banner = %q, want the identification line only", result.Banner)
+	}
+	if details := portDetails(result); len(details) != 1 || !strings.HasPrefix(details[0], "🔑 SSH: ssh-ed25519 (256) SHA256:") {
+		t.Errorf("portDetails = %q", details)
+	}
+}
@@ -0,0 +1,413 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// addrRange is an inclusive range of addresses of the same family
+type addrRange struct {
+	from, to netip.Addr
+}
+
+// targetItem is one parsed target: an address range or a hostname resolved
+// when the iterator reaches it
+type targetItem struct {
+	addrs    addrRange
+	hostname string
+}
+
+// TargetIterator yields scan targets one address at a time, so a /8 costs
+// no more memory than a single host. Targets are IP addresses, CIDR
+// networks, ranges (10.0.0.1-50, 10.0.0.1-10.0.3.255) and hostnames;
+// addresses matching an exclusion are skipped.
+type TargetIterator struct {
+	items      []targetItem
+	exclude    []addrRange // Sorted and merged
+	current    addrRange
+	next       netip.Addr
+	inRange    bool
+	count      int
+	unresolved []string
+}
+
+// NewTargetIterator parses targets and exclusions. Each entry may itself be
+// a comma or space separated list. Exclusions accept every target syntax;
+// excluded hostnames are resolved immediately.
+func NewTargetIterator(targets, exclude []string) (*TargetIterator, error) {
+	it := &TargetIterator{}
+	var neighbors []Neighbor
+	neighborsLoaded := false
+
+	for _, target := range splitTargets(targets) {
+		item, err := parseTarget(target)
+		if err != nil {
+			return nil, err
+		}
+
+		// Large IPv6 prefixes: scan the hosts seen on the local links
+		if prefix, ok := largeIPv6Prefix(target); ok {
+			if !neighborsLoaded {
+				neighbors, err = Neighbors()
+				if err != nil {
+					return nil, fmt.Errorf("IPv6 prefix %s is too large to enumerate and %v", target, err)
+				}
+				neighborsLoaded = true
+			}
+			found := neighborsInPrefix(neighbors, prefix)
+			if len(found) == 0 {
+				return nil, fmt.Errorf("IPv6 prefix %s is too large to enumerate and no neighbour-discovered host belongs to it; list the hosts explicitly", target)
+			}
+			for _, ip := range found {
+				addr := netip.MustParseAddr(ip)
+				it.items = append(it.items, targetItem{addrs: addrRange{addr, addr}})
+			}
+			continue
+		}
+
+		it.items = append(it.items, item)
+	}
+	if len(it.items) == 0 {
+		return nil, fmt.Errorf("no targets specified")
+	}
+
+	for _, target := range splitTargets(exclude) {
+		if prefix, err := netip.ParsePrefix(target); err == nil {
+			// Exclusions never enumerate, so any prefix size is fine
+			prefix = prefix.Masked()
+			it.exclude = append(it.exclude, addrRange{prefix.Addr(), lastAddr(prefix)})
+			continue
+		}
+
+		item, err := parseTarget(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclusion: %v", err)
+		}
+		if item.hostname == "" {
+			it.exclude = append(it.exclude, item.addrs)
+			continue
+		}
+
+		addrs, err := net.DefaultResolver.LookupNetIP(context.Background(), "ip", item.hostname)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclusion: cannot resolve %s: %v", item.hostname, err)
+		}
+		for _, addr := range addrs {
+			addr = addr.Unmap()
+			it.exclude = append(it.exclude, addrRange{addr, addr})
+		}
+	}
+	it.exclude = mergeRanges(it.exclude)
+
+	for _, item := range it.items {
+		if item.hostname != "" {
+			it.count++
+			continue
+		}
+		it.count += rangeSize(item.addrs) - excludedCount(item.addrs, it.exclude)
+	}
+
+	return it, nil
+}
+
+// Count returns the number of addresses the iterator yields, counting each
+// hostname as one address
+func (it *TargetIterator) Count() int {
+	return it.count
+}
+
+// Unresolved returns the hostnames that could not be resolved so far
+func (it *TargetIterator) Unresolved() []string {
+	return it.unresolved
+}
+
+// Next returns the next target address. It returns false when every target
+// was produced or ctx was cancelled. Hostnames are resolved here, using the
+// first address returned by the resolver; failures are recorded in
+// Unresolved and skipped.
+func (it *TargetIterator) Next(ctx context.Context) (string, bool) {
+	for ctx.Err() == nil {
+		if it.inRange {
+			addr := it.next
+			if addr == it.current.to {
+				it.inRange = false
+			} else {
+				it.next = addr.Next()
+			}
+			if !it.excluded(addr) {
+				return addr.String(), true
+			}
+			continue
+		}
+
+		if len(it.items) == 0 {
+			return "", false
+		}
+		item := it.items[0]
+		it.items = it.items[1:]
+
+		if item.hostname == "" {
+			it.current, it.next, it.inRange = item.addrs, item.addrs.from, true
+			continue
+		}
+
+		addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", item.hostname)
+		if err != nil || len(addrs) == 0 {
+			if ctx.Err() == nil {
+				it.unresolved = append(it.unresolved, item.hostname)
+			}
+			continue
+		}
+		if addr := addrs[0].Unmap(); !it.excluded(addr) {
+			return addr.String(), true
+		}
+	}
+	return "", false
+}
+
+// excluded reports whether addr falls in an exclusion
+func (it *TargetIterator) excluded(addr netip.Addr) bool {
+	addr = addr.WithZone("")
+	for _, r := range it.exclude {
+		if addr.Compare(r.from) >= 0 && addr.Compare(r.to) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadTargetList reads a target inventory: one target per line (several
+// may share a line, separated by commas or spaces), blank lines and
+// comments starting with '#' ignored. Every target is validated, and the
+// error names the offending line.
+func ReadTargetList(r io.Reader) ([]string, error) {
+	var targets []string
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		for _, target := range splitTargets([]string{text}) {
+			if _, err := parseTarget(target); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			targets = append(targets, target)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading target list: %v", err)
+	}
+	return targets, nil
+}
+
+// ReadTargetFile reads a target inventory with ReadTargetList from path,
+// or from standard input when path is "-"
+func ReadTargetFile(path string) ([]string, error) {
+	if path == "-" {
+		return ReadTargetList(os.Stdin)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening target list: %v", err)
+	}
+	defer file.Close()
+
+	targets, err := ReadTargetList(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return targets, nil
+}
+
+// splitTargets splits entries on commas and whitespace
+func splitTargets(entries []string) []string {
+	var targets []string
+	for _, entry := range entries {
+		targets = append(targets, strings.FieldsFunc(entry, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		})...)
+	}
+	return targets
+}
+
+// parseTarget parses an address, CIDR network, range or hostname
+func parseTarget(target string) (targetItem, error) {
+	if addr, err := netip.ParseAddr(target); err == nil {
+		addr = addr.Unmap()
+		return targetItem{addrs: addrRange{addr, addr}}, nil
+	}
+
+	if strings.Contains(target, "/") {
+		prefix, err := netip.ParsePrefix(target)
+		if err != nil {
+			return targetItem{}, fmt.Errorf("invalid target %q: %v", target, err)
+		}
+		if _, large := largeIPv6Prefix(target); large {
+			return targetItem{}, nil // Expanded from the neighbour table
+		}
+		return targetItem{addrs: prefixHosts(prefix.Masked())}, nil
+	}
+
+	if from, to, found := strings.Cut(target, "-"); found {
+		if start, err := netip.ParseAddr(from); err == nil {
+			r, err := parseAddrRange(start.Unmap(), to)
+			if err != nil {
+				return targetItem{}, fmt.Errorf("invalid range %q: %v", target, err)
+			}
+			return targetItem{addrs: r}, nil
+		}
+	}
+
+	if !isHostname(target) {
+		return targetItem{}, fmt.Errorf("invalid target %q: expected an IP address, CIDR network, range or hostname", target)
+	}
+	return targetItem{hostname: target}, nil
+}
+
+// parseAddrRange completes a range from its first address and its end,
+// either a full address or the last IPv4 octet
+func parseAddrRange(from netip.Addr, end string) (addrRange, error) {
+	to, err := netip.ParseAddr(end)
+	if err != nil {
+		octet, convErr := strconv.Atoi(end)
+		if convErr != nil || !from.Is4() || octet < 0 || octet > 255 {
+			return addrRange{}, fmt.Errorf("end must be an address or the last IPv4 octet (0-255)")
+		}
+		bytes := from.As4()
+		bytes[3] = byte(octet)
+		to = netip.AddrFrom4(bytes)
+	}
+	to = to.Unmap()
+
+	switch {
+	case from.Is4() != to.Is4():
+		return addrRange{}, fmt.Errorf("addresses of different families")
+	case to.Less(from):
+		return addrRange{}, fmt.Errorf("end is before start")
+	case from.Is6() && rangeSize(addrRange{from, to}) > 1<<MaxIPv6PrefixBits:
+		return addrRange{}, fmt.Errorf("more than %d IPv6 addresses", 1<<MaxIPv6PrefixBits)
+	}
+	return addrRange{from, to}, nil
+}
+
+// largeIPv6Prefix reports whether target is an IPv6 prefix too large to
+// enumerate
+func largeIPv6Prefix(target string) (netip.Prefix, bool) {
+	prefix, err := netip.ParsePrefix(target)
+	if err != nil || !prefix.Addr().Is6() || prefix.Addr().Is4In6() {
+		return netip.Prefix{}, false
+	}
+	return prefix.Masked(), 128-prefix.Bits() > MaxIPv6PrefixBits
+}
+
+// prefixHosts returns the scannable addresses of a prefix. Like ParseCIDR,
+// IPv4 networks with more than two addresses skip the network and
+// broadcast addresses.
+func prefixHosts(prefix netip.Prefix) addrRange {
+	r := addrRange{prefix.Addr(), lastAddr(prefix)}
+	if prefix.Addr().Is4() && prefix.Bits() < 31 {
+		r.from, r.to = r.from.Next(), r.to.Prev()
+	}
+	return r
+}
+
+// lastAddr returns the last address of a prefix
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	bytes := prefix.Addr().As16()
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	for i := 15; hostBits > 0; i-- {
+		bits := hostBits
+		if bits > 8 {
+			bits = 8
+		}
+		bytes[i] |= byte(1<<bits - 1)
+		hostBits -= bits
+	}
+	addr := netip.AddrFrom16(bytes)
+	if prefix.Addr().Is4() {
+		return addr.Unmap()
+	}
+	return addr
+}
+
+// isHostname reports whether name is a syntactically valid DNS name
+func isHostname(name string) bool {
+	if len(name) == 0 || len(name) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// rangeSize returns the number of addresses in r, saturating at MaxInt
+func rangeSize(r addrRange) int {
+	from, to := r.from.WithZone("").As16(), r.to.WithZone("").As16()
+	fromHigh, toHigh := binary.BigEndian.Uint64(from[:8]), binary.BigEndian.Uint64(to[:8])
+	fromLow, toLow := binary.BigEndian.Uint64(from[8:]), binary.BigEndian.Uint64(to[8:])
+
+	low := toLow - fromLow
+	if toLow < fromLow {
+		toHigh-- // Borrow
+	}
+	if toHigh != fromHigh || low >= uint64(1<<62) {
+		return int(^uint(0) >> 1)
+	}
+	return int(low) + 1
+}
+
+// excludedCount returns how many addresses of r fall in the merged exclusions
+func excludedCount(r addrRange, exclude []addrRange) int {
+	count := 0
+	from, to := r.from.WithZone(""), r.to.WithZone("")
+	for _, ex := range exclude {
+		if ex.from.Is4() != from.Is4() || ex.to.Less(from) || to.Less(ex.from) {
+			continue
+		}
+		overlap := addrRange{from, to}
+		if from.Less(ex.from) {
+			overlap.from = ex.from
+		}
+		if ex.to.Less(to) {
+			overlap.to = ex.to
+		}
+		count += rangeSize(overlap)
+	}
+	return count
+}
+
+// mergeRanges sorts ranges and merges the overlapping or adjacent ones
+func mergeRanges(ranges []addrRange) []addrRange {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].from.Less(ranges[j].from) })
+
+	var merged []addrRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if last.to.Is4() == r.from.Is4() && (!last.to.Less(r.from) || last.to.Next() == r.from) {
+				if last.to.Less(r.to) {
+					last.to = r.to
+				}
+				continue
+			}
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -0,0 +1,185 @@
+package network
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// collectTargets drains the iterator
+func collectTargets(t *testing.T, it *TargetIterator) []string {
+	t.Helper()
+	var ips []string
+	for {
+		ip, ok := it.Next(context.Background())
+		if !ok {
+			return ips
+		}
+		ips = append(ips, ip)
+	}
+}
+
+func TestTargetIterator(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets string
+		exclude string
+		want    string
+	}{
+		{
+			name:    "addresses and networks",
+			targets: "10.0.0.1, 2001:db8::1 fe80::1%eth0,192.168.1.0/30,::ffff:10.0.0.9",
+			want:    "10.0.0.1 2001:db8::1 fe80::1%eth0 192.168.1.1 192.168.1.2 10.0.0.9",
+		},
+		{name: "last octet range", targets: "10.0.0.1-4", want: "10.0.0.1 10.0.0.2 10.0.0.3 10.0.0.4"},
+		{name: "full range", targets: "10.0.0.254-10.0.1.1", want: "10.0.0.254 10.0.0.255 10.0.1.0 10.0.1.1"},
+		{name: "IPv6 range", targets: "2001:db8::fe-2001:db8::101", want: "2001:db8::fe 2001:db8::ff 2001:db8::100 2001:db8::101"},
+		{name: "point to point network", targets: "10.0.0.0/31", want: "10.0.0.0 10.0.0.1"},
+		{
+			name:    "exclusions",
+			targets: "10.0.0.0/29",
+			exclude: "10.0.0.2,10.0.0.4-5 10.0.0.6/32",
+			want:    "10.0.0.1 10.0.0.3",
+		},
+		{name: "exclusion prefix", targets: "10.0.0.1-3,10.0.1.1", exclude: "10.0.0.0/8", want: ""},
+		{name: "zone ignored by exclusions", targets: "fe80::1%eth0,fe80::2%eth0", exclude: "fe80::1", want: "fe80::2%eth0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it, err := NewTargetIterator([]string{tt.targets}, []string{tt.exclude})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := collectTargets(t, it)
+
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("targets = %q, want %q", strings.Join(got, " "), tt.want)
+			}
+			if it.Count() != len(got) {
+				t.Errorf("Count() = %d, want %d", it.Count(), len(got))
+			}
+		})
+	}
+}
+
+func TestTargetIteratorErrors(t *testing.T) {
+	tests := []struct {
+		targets, exclude, wantErr string
+	}{
+		{"", "", "no targets"},
+		{"10.0.0.0/33", "", "invalid target"},
+		{"10.0.0.5-1", "", "end is before start"},
+		{"10.0.0.1-256", "", "last IPv4 octet"},
+		{"10.0.0.1-2001:db8::1", "", "different families"},
+		{"2001:db8::-2001:db8::1:0", "", "IPv6 addresses"},
+		{"bad_host!", "", "invalid target"},
+		{"10.0.0.1", "10.0.0.9-1", "invalid exclusion"},
+	}
+
+	for _, tt := range tests {
+		_, err := NewTargetIterator([]string{tt.targets}, []string{tt.exclude})
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("NewTargetIterator(%q, %q) error = %v, want %q", tt.targets, tt.exclude, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTargetIteratorLarge(t *testing.T) {
+	// A /8 is counted arithmetically and generated lazily
+	it, err := NewTargetIterator([]string{"10.0.0.0/8"}, []string{"10.1.0.0/16, 10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 1<<24 - 2 - 1<<16 - 1; it.Count() != want {
+		t.Errorf("Count() = %d, want %d", it.Count(), want)
+	}
+
+	first, _ := it.Next(context.Background())
+	if first != "10.0.0.2" {
+		t.Errorf("first target = %s, want 10.0.0.2", first)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if ip, ok := it.Next(ctx); ok {
+		t.Errorf("Next after cancel = %s, want no target", ip)
+	}
+}
+
+func TestTargetIteratorUnresolved(t *testing.T) {
+	it, err := NewTargetIterator([]string{"10.0.0.1,does-not-exist.invalid"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if it.Count() != 2 {
+		t.Errorf("Count() = %d, want 2", it.Count())
+	}
+	got := collectTargets(t, it)
+	if strings.Join(got, " ") != "10.0.0.1" {
+		t.Errorf("targets = %v, want [10.0.0.1]", got)
+	}
+	if strings.Join(it.Unresolved(), " ") != "does-not-exist.invalid" {
+		t.Errorf("Unresolved() = %v, want [does-not-exist.invalid]", it.Unresolved())
+	}
+}
+
+func TestReadTargetList(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "mixed inventory",
+			input: "# office\n192.168.1.0/24\n\n10.0.0.1-50  # lab\r\nweb.example.com, 2001:db8::1\n   \n",
+			want:  "192.168.1.0/24 10.0.0.1-50 web.example.com 2001:db8::1",
+		},
+		{name: "only comments", input: "# nothing\n#\n", want: ""},
+		{name: "invalid line", input: "10.0.0.1\n10.0.0.9-2\n", wantErr: "line 2: invalid range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadTargetList(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("targets = %q, want %q", strings.Join(got, " "), tt.want)
+			}
+		})
+	}
+}
+
+func TestReadTargetFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.txt")
+	if err := os.WriteFile(path, []byte("10.0.0.1-3\n10.0.0.9\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := ReadTargetFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	it, err := NewTargetIterator(targets, []string{"10.0.0.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(collectTargets(t, it), " "); got != "10.0.0.1 10.0.0.3 10.0.0.9" {
+		t.Errorf("targets = %q, want %q", got, "10.0.0.1 10.0.0.3 10.0.0.9")
+	}
+
+	if _, err := ReadTargetFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("ReadTargetFile on a missing file succeeded, want an error")
+	}
+}
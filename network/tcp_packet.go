@@ -0,0 +1,84 @@
+package network
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/netip"
+)
+
+// TCP header flags
+const (
+	tcpFIN = 0x01
+	tcpSYN = 0x02
+	tcpRST = 0x04
+	tcpPSH = 0x08
+	tcpACK = 0x10
+	tcpURG = 0x20
+)
+
+// tcpSegment is the part of a TCP header the raw scans look at
+type tcpSegment struct {
+	srcPort, dstPort uint16
+	seq, ack         uint32
+	flags            uint8
+	window           uint16
+}
+
+// rawWindow is the window advertised by the raw probes, like nmap's
+const rawWindow = 1024
+
+// buildTCPSegment encodes a TCP header with the flags of a probe. SYN
+// probes carry an MSS option, as real stacks do. The checksum is computed
+// over the IPv4 or IPv6 pseudo-header of src and dst.
+func buildTCPSegment(src, dst netip.Addr, segment tcpSegment) []byte {
+	headerLen := 20
+	if segment.flags&tcpSYN != 0 {
+		headerLen = 24
+	}
+	header := make([]byte, headerLen)
+	binary.BigEndian.PutUint16(header[0:], segment.srcPort)
+	binary.BigEndian.PutUint16(header[2:], segment.dstPort)
+	binary.BigEndian.PutUint32(header[4:], segment.seq)
+	binary.BigEndian.PutUint32(header[8:], segment.ack)
+	header[12] = byte(headerLen/4) << 4
+	header[13] = segment.flags
+	binary.BigEndian.PutUint16(header[14:], segment.window)
+	if headerLen == 24 {
+		copy(header[20:], []byte{2, 4, 0x05, 0xb4}) // MSS 1460
+	}
+	binary.BigEndian.PutUint16(header[16:], tcpChecksum(src, dst, header))
+	return header
+}
+
+// parseTCPSegment decodes the start of a TCP header
+func parseTCPSegment(data []byte) (tcpSegment, error) {
+	if len(data) < 20 {
+		return tcpSegment{}, errors.New("short TCP header")
+	}
+	return tcpSegment{
+		srcPort: binary.BigEndian.Uint16(data[0:]),
+		dstPort: binary.BigEndian.Uint16(data[2:]),
+		seq:     binary.BigEndian.Uint32(data[4:]),
+		ack:     binary.BigEndian.Uint32(data[8:]),
+		flags:   data[13],
+		window:  binary.BigEndian.Uint16(data[14:]),
+	}, nil
+}
+
+// tcpChecksum computes the TCP checksum of segment over the pseudo-header
+// of src and dst (RFC 793, RFC 8200 section 8.1)
+func tcpChecksum(src, dst netip.Addr, segment []byte) uint16 {
+	var pseudo []byte
+	if src.Is4() {
+		s, d := src.As4(), dst.As4()
+		pseudo = append(append(pseudo, s[:]...), d[:]...)
+		pseudo = append(pseudo, 0, 6)
+		pseudo = binary.BigEndian.AppendUint16(pseudo, uint16(len(segment)))
+	} else {
+		s, d := src.As16(), dst.As16()
+		pseudo = append(append(pseudo, s[:]...), d[:]...)
+		pseudo = binary.BigEndian.AppendUint32(pseudo, uint32(len(segment)))
+		pseudo = append(pseudo, 0, 0, 0, 6)
+	}
+	return icmpChecksum(append(pseudo, segment...))
+}
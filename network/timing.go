@@ -0,0 +1,150 @@
+package network
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimingTemplate sets the speed of a scan, like nmap's -T0 to -T5: how many
+// probes are in flight, how long they wait for an answer, how far apart
+// they are sent and how often they are retransmitted
+type TimingTemplate struct {
+	Level       int           // 0 (paranoid) to 5 (insane)
+	Name        string        // paranoid, sneaky, polite, normal, aggressive or insane
+	Parallelism int           // Probes in flight per host
+	Hosts       int           // Hosts scanned at once by network scans
+	Timeout     time.Duration // Per-probe timeout, the initial one when Adaptive
+	MinTimeout  time.Duration // Bounds of the adaptive timeout
+	MaxTimeout  time.Duration
+	ScanDelay   time.Duration // Wait between two probes to the same host
+	Retries     int           // Retransmissions of unanswered UDP and raw TCP probes
+	Adaptive    bool          // Derive the timeout from the round-trip times measured on the host
+}
+
+// TimingTemplates are the templates by level. T0 and T1 send one probe at
+// a time, minutes or seconds apart, to slip under IDS thresholds; T2 spaces
+// probes to spare the network; T3 is the default; T4 suits fast, reliable
+// networks and T5 trades accuracy for speed.
+var TimingTemplates = [...]TimingTemplate{
+	{Level: 0, Name: "paranoid", Parallelism: 1, Hosts: 1, Timeout: 5 * time.Second, MinTimeout: 5 * time.Second, MaxTimeout: 5 * time.Second,
+		ScanDelay: 5 * time.Minute, Retries: 2},
+	{Level: 1, Name: "sneaky", Parallelism: 1, Hosts: 1, Timeout: 5 * time.Second, MinTimeout: 5 * time.Second, MaxTimeout: 5 * time.Second,
+		ScanDelay: 15 * time.Second, Retries: 2},
+	{Level: 2, Name: "polite", Parallelism: 1, Hosts: 1, Timeout: 2 * time.Second, MinTimeout: 100 * time.Millisecond, MaxTimeout: 10 * time.Second,
+		ScanDelay: 400 * time.Millisecond, Retries: 2, Adaptive: true},
+	{Level: 3, Name: "normal", Parallelism: 10, Hosts: 10, Timeout: 2 * time.Second, MinTimeout: 100 * time.Millisecond, MaxTimeout: 10 * time.Second,
+		Retries: 1, Adaptive: true},
+	{Level: 4, Name: "aggressive", Parallelism: 50, Hosts: 25, Timeout: time.Second, MinTimeout: 100 * time.Millisecond, MaxTimeout: 1250 * time.Millisecond,
+		Retries: 1, Adaptive: true},
+	{Level: 5, Name: "insane", Parallelism: 100, Hosts: 50, Timeout: 250 * time.Millisecond, MinTimeout: 50 * time.Millisecond, MaxTimeout: 300 * time.Millisecond,
+		Adaptive: true},
+}
+
+// DefaultTiming is the level used when a config does not select a template
+const DefaultTiming = 3
+
+// ParseTiming returns the template named by spec: "T4", "4" or
+// "aggressive". An empty spec selects DefaultTiming.
+func ParseTiming(spec string) (TimingTemplate, error) {
+	name := strings.ToLower(strings.TrimSpace(spec))
+	if name == "" {
+		return TimingTemplates[DefaultTiming], nil
+	}
+	for _, template := range TimingTemplates {
+		if name == template.Name || strings.TrimPrefix(name, "t") == fmt.Sprint(template.Level) {
+			return template, nil
+		}
+	}
+	return TimingTemplate{}, fmt.Errorf("unknown timing template %q (supported: T0 to T5, paranoid, sneaky, polite, normal, aggressive, insane)", spec)
+}
+
+// String returns the label shown in reports, e.g. "Aggressive (T4)"
+func (t TimingTemplate) String() string {
+	if t.Name == "" {
+		return fmt.Sprintf("T%d", t.Level)
+	}
+	return fmt.Sprintf("%s%s (T%d)", strings.ToUpper(t.Name[:1]), t.Name[1:], t.Level)
+}
+
+// resolveTiming returns the template named by spec with the threads and
+// timeout of a config overriding it when set. An explicit timeout is also
+// the longest the adaptive timeout may grow to.
+func resolveTiming(spec string, threads int, timeout time.Duration) (TimingTemplate, error) {
+	timing, err := ParseTiming(spec)
+	if err != nil {
+		return TimingTemplate{}, err
+	}
+	if threads > 0 {
+		timing.Parallelism = threads
+	}
+	if timeout > 0 {
+		timing.Timeout, timing.MaxTimeout = timeout, timeout
+		if timing.MinTimeout > timeout {
+			timing.MinTimeout = timeout
+		}
+	}
+	return timing, nil
+}
+
+// timeoutProber is implemented by the probers whose timeout adaptive
+// timing may tune: the ones classifying a port from a single answer.
+// Service detection keeps its timeout, slow banners included.
+type timeoutProber interface {
+	withTimeout(timeout time.Duration) Prober
+}
+
+// adaptiveTimeout derives the probe timeout from the round-trip times
+// measured on a host, like TCP's retransmission timer (RFC 6298): the
+// smoothed RTT plus four times its variation, within the template bounds
+type adaptiveTimeout struct {
+	mu           sync.Mutex
+	timeout      time.Duration
+	min, max     time.Duration
+	srtt, rttvar time.Duration
+}
+
+// newAdaptiveTimeout starts from the template timeout, or returns nil when
+// the template is not adaptive
+func newAdaptiveTimeout(timing TimingTemplate) *adaptiveTimeout {
+	if !timing.Adaptive {
+		return nil
+	}
+	return &adaptiveTimeout{timeout: timing.Timeout, min: timing.MinTimeout, max: timing.MaxTimeout}
+}
+
+// current returns the timeout of the next probe
+func (a *adaptiveTimeout) current() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.timeout
+}
+
+// observe updates the timeout with the round trip of an answered probe;
+// unanswered probes say nothing about it
+func (a *adaptiveTimeout) observe(result PortResult) {
+	switch result.State {
+	case StateOpen, StateClosed, StateUnfiltered:
+	default:
+		return
+	}
+	rtt := result.ResponseTime
+	if rtt <= 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.srtt == 0 {
+		a.srtt, a.rttvar = rtt, rtt/2
+	} else {
+		delta := a.srtt - rtt
+		if delta < 0 {
+			delta = -delta
+		}
+		a.rttvar = (3*a.rttvar + delta) / 4
+		a.srtt = (7*a.srtt + rtt) / 8
+	}
+	a.timeout = min(max(a.srtt+4*a.rttvar, a.min), a.max)
+}
@@ -0,0 +1,176 @@
+package network
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseTiming(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    int
+		wantErr bool
+	}{
+		{"", DefaultTiming, false},
+		{"T4", 4, false},
+		{" t0 ", 0, false},
+		{"5", 5, false},
+		{"Polite", 2, false},
+		{"T6", 0, true},
+		{"fast", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTiming(tt.spec)
+		if (err != nil) != tt.wantErr || (err == nil && got.Level != tt.want) {
+			t.Errorf("ParseTiming(%q) = T%d, %v; want T%d, error %v", tt.spec, got.Level, err, tt.want, tt.wantErr)
+		}
+	}
+
+	for level, template := range TimingTemplates {
+		if template.Level != level || template.Parallelism < 1 || template.Hosts < 1 ||
+			template.MinTimeout > template.Timeout || template.Timeout > template.MaxTimeout {
+			t.Errorf("inconsistent template %+v", template)
+		}
+	}
+	if got := TimingTemplates[4].String(); got != "Aggressive (T4)" {
+		t.Errorf("T4 label = %q", got)
+	}
+}
+
+func TestResolveTiming(t *testing.T) {
+	timing, err := resolveTiming("T4", 0, 0)
+	if err != nil || timing != TimingTemplates[4] {
+		t.Errorf("resolveTiming without overrides = %+v, %v; want T4", timing, err)
+	}
+
+	// Explicit settings win, and the timeout caps the adaptive one
+	timing, _ = resolveTiming("T3", 7, 50*time.Millisecond)
+	if timing.Parallelism != 7 || timing.Timeout != 50*time.Millisecond ||
+		timing.MaxTimeout != 50*time.Millisecond || timing.MinTimeout != 50*time.Millisecond {
+		t.Errorf("resolveTiming with overrides = %+v", timing)
+	}
+	if _, err := resolveTiming("T7", 0, 0); err == nil {
+		t.Error("resolveTiming accepted T7")
+	}
+}
+
+func TestAdaptiveTimeout(t *testing.T) {
+	if newAdaptiveTimeout(TimingTemplates[0]) != nil {
+		t.Error("T0 is not adaptive")
+	}
+
+	timeouts := newAdaptiveTimeout(TimingTemplates[3])
+	if got := timeouts.current(); got != 2*time.Second {
+		t.Fatalf("initial timeout = %v, want the template's 2s", got)
+	}
+
+	// Unanswered probes leave the timeout alone
+	timeouts.observe(PortResult{State: StateFiltered, ResponseTime: 2 * time.Second})
+	if got := timeouts.current(); got != 2*time.Second {
+		t.Errorf("timeout after a filtered port = %v, want 2s", got)
+	}
+
+	// A fast host brings it down to the minimum, a slow one up to the maximum
+	for i := 0; i < 20; i++ {
+		timeouts.observe(PortResult{State: StateClosed, ResponseTime: time.Millisecond})
+	}
+	if got := timeouts.current(); got != 100*time.Millisecond {
+		t.Errorf("timeout after 1ms answers = %v, want the 100ms minimum", got)
+	}
+	timeouts.observe(PortResult{State: StateOpen, ResponseTime: 400 * time.Millisecond})
+	if got := timeouts.current(); got <= 100*time.Millisecond || got >= 2*time.Second {
+		t.Errorf("timeout after a 400ms answer = %v, want it raised", got)
+	}
+	for i := 0; i < 50; i++ {
+		timeouts.observe(PortResult{State: StateOpen, ResponseTime: 20 * time.Second})
+	}
+	if got := timeouts.current(); got != 10*time.Second {
+		t.Errorf("timeout after 20s answers = %v, want the 10s maximum", got)
+	}
+}
+
+// timeoutRecorder answers every probe after a fixed delay and records the
+// timeouts it was tuned to
+type timeoutRecorder struct {
+	timeout time.Duration
+	rtt     time.Duration
+	mu      *sync.Mutex
+	seen    *[]time.Duration
+}
+
+func (p timeoutRecorder) Protocol() string { return "tcp" }
+
+func (p timeoutRecorder) Probe(ctx context.Context, ip string, port int) PortResult {
+	p.mu.Lock()
+	*p.seen = append(*p.seen, p.timeout)
+	p.mu.Unlock()
+	return PortResult{IP: ip, Port: port, Protocol: "tcp", State: StateClosed, ResponseTime: p.rtt}
+}
+
+func (p timeoutRecorder) withTimeout(timeout time.Duration) Prober {
+	p.timeout = timeout
+	return p
+}
+
+func TestScanWithProbersTiming(t *testing.T) {
+	var seen []time.Duration
+	prober := timeoutRecorder{timeout: time.Hour, rtt: time.Millisecond, mu: &sync.Mutex{}, seen: &seen}
+
+	timing := TimingTemplates[2]
+	timing.ScanDelay = 20 * time.Millisecond
+	start := time.Now()
+	results := scanWithProbers(context.Background(), []Prober{prober}, timing, "192.0.2.1", uniformPorts([]int{1, 2, 3, 4}), nil)
+	if elapsed := time.Since(start); len(results) != 4 || elapsed < 60*time.Millisecond {
+		t.Errorf("4 ports with a 20ms delay took %v (%d results), want at least 60ms", elapsed, len(results))
+	}
+
+	// The first probe waits for the template timeout, the next ones adapt
+	if len(seen) != 4 || seen[0] != timing.Timeout || seen[3] != timing.MinTimeout {
+		t.Errorf("probe timeouts = %v, want %v then down to %v", seen, timing.Timeout, timing.MinTimeout)
+	}
+}
+
+// scanInfoRecorder keeps the ScanInfo of the last scan started
+type scanInfoRecorder struct {
+	NopObserver
+	info *ScanInfo
+}
+
+func (r scanInfoRecorder) ScanStarted(info ScanInfo) { *r.info = info }
+
+func TestScanConfigTiming(t *testing.T) {
+	var info ScanInfo
+	observer := scanInfoRecorder{info: &info}
+
+	stealthy := StealthyScanConfig{TargetIP: "127.0.0.1", StartPort: 1, EndPort: 1, ScanType: ScanTypeConnect,
+		AggressiveTiming: true, Observer: observer}
+	if _, err := ScanHostStealthyContext(context.Background(), stealthy); err != nil {
+		t.Fatalf("stealth scan: %v", err)
+	}
+	if info.Timing != "Aggressive (T4)" || info.Threads != 50 || info.Timeout != time.Second {
+		t.Errorf("AggressiveTiming scan info = %+v, want T4 with 50 threads and 1s", info)
+	}
+
+	stealthy.Timing, stealthy.Threads = "T5", 10
+	if _, err := ScanHostStealthyContext(context.Background(), stealthy); err != nil {
+		t.Fatalf("stealth scan: %v", err)
+	}
+	if info.Timing != "Insane (T5)" || info.Threads != 10 || info.Timeout != 250*time.Millisecond {
+		t.Errorf("T5 scan info = %+v, want T5 with 10 threads and 250ms", info)
+	}
+
+	stealthy.Timing = "T8"
+	if _, err := ScanHostStealthyContext(context.Background(), stealthy); err == nil {
+		t.Error("ScanHostStealthyContext accepted T8")
+	}
+
+	network := NetworkScanConfig{Network: "127.0.0.1", PortRange: "1", Timing: "polite", SkipDiscovery: true, Observer: observer}
+	if _, err := ScanNetworkReportContext(context.Background(), network); err != nil {
+		t.Fatalf("network scan: %v", err)
+	}
+	if info.Timing != "Polite (T2)" || info.Threads != 1 || info.Timeout != 2*time.Second {
+		t.Errorf("T2 scan info = %+v, want T2 with 1 thread and 2s", info)
+	}
+}
@@ -0,0 +1,195 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLSInfo describes the TLS handshake of a port and the certificate the
+// server presented
+type TLSInfo struct {
+	Version            string    `json:"version"` // Negotiated protocol (e.g., TLS 1.3)
+	CipherSuite        string    `json:"cipher_suite"`
+	Subject            string    `json:"subject"`
+	SANs               []string  `json:"sans,omitempty"` // DNS names, IP addresses and emails
+	Issuer             string    `json:"issuer"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	KeyType            string    `json:"key_type"` // e.g. RSA 2048, ECDSA P-256
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	Expired            bool      `json:"expired"`
+	SelfSigned         bool      `json:"self_signed"`
+	Weaknesses         []string  `json:"weaknesses,omitempty"` // Weak protocol, cipher, key or signature
+}
+
+// Issues returns the problems of the TLS service, for the reports
+func (t *TLSInfo) Issues() []string {
+	var issues []string
+	if t.Expired {
+		issues = append(issues, "expired")
+	}
+	if t.SelfSigned {
+		issues = append(issues, "self-signed")
+	}
+	return append(issues, t.Weaknesses...)
+}
+
+// minRSABits is the smallest RSA key not reported as weak
+const minRSABits = 2048
+
+// InspectTLS connects to ip:port, performs a TLS handshake and describes
+// the negotiated parameters and the server certificate. Certificates are
+// not verified, so invalid ones are reported instead of rejected.
+func InspectTLS(ctx context.Context, ip string, port int, timeout time.Duration) (*TLSInfo, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	tlsConn, info, err := tlsHandshake(ctx, conn, timeout)
+	if err != nil {
+		return nil, err
+	}
+	tlsConn.Close()
+	return info, nil
+}
+
+// tlsHandshake runs a TLS client handshake over conn. On failure conn is
+// closed; on success the TLS connection is returned for further probes.
+func tlsHandshake(ctx context.Context, conn net.Conn, timeout time.Duration) (*tls.Conn, *TLSInfo, error) {
+	tlsConn := tls.Client(conn, tlsProbeConfig())
+	handshakeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := tlsConn.HandshakeContext(handshakeCtx); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("tls handshake: %v", err)
+	}
+
+	state := tlsConn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		tlsConn.Close()
+		return nil, nil, errors.New("tls handshake: no server certificate")
+	}
+	return tlsConn, describeTLS(state, time.Now()), nil
+}
+
+// tlsProbeConfig accepts every certificate and offers the legacy protocol
+// versions and cipher suites too, so weak servers still complete the
+// handshake and get reported
+func tlsProbeConfig() *tls.Config {
+	var suites []uint16
+	for _, suite := range tls.CipherSuites() {
+		suites = append(suites, suite.ID)
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		suites = append(suites, suite.ID)
+	}
+	return &tls.Config{
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS10,
+		CipherSuites:       suites,
+	}
+}
+
+// describeTLS builds the TLSInfo of a completed handshake at time now
+func describeTLS(state tls.ConnectionState, now time.Time) *TLSInfo {
+	cert := state.PeerCertificates[0]
+	info := &TLSInfo{
+		Version:            tls.VersionName(state.Version),
+		CipherSuite:        tls.CipherSuiteName(state.CipherSuite),
+		Subject:            cert.Subject.String(),
+		SANs:               certificateSANs(cert),
+		Issuer:             cert.Issuer.String(),
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		KeyType:            publicKeyType(cert),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		Expired:            now.After(cert.NotAfter),
+		SelfSigned:         isSelfSigned(cert),
+	}
+	info.Weaknesses = tlsWeaknesses(state.Version, state.CipherSuite, cert, now)
+	return info
+}
+
+// tlsWeaknesses lists the weak settings of a handshake
+func tlsWeaknesses(version, cipherSuite uint16, cert *x509.Certificate, now time.Time) []string {
+	var weaknesses []string
+	if version < tls.VersionTLS12 {
+		weaknesses = append(weaknesses, "weak protocol "+tls.VersionName(version))
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.ID == cipherSuite {
+			weaknesses = append(weaknesses, "weak cipher "+suite.Name)
+		}
+	}
+	if key, ok := cert.PublicKey.(*rsa.PublicKey); ok && key.N.BitLen() < minRSABits {
+		weaknesses = append(weaknesses, fmt.Sprintf("weak key RSA %d", key.N.BitLen()))
+	}
+	switch cert.SignatureAlgorithm {
+	case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+		weaknesses = append(weaknesses, "weak signature "+cert.SignatureAlgorithm.String())
+	}
+	if now.Before(cert.NotBefore) {
+		weaknesses = append(weaknesses, "not yet valid")
+	}
+	return weaknesses
+}
+
+// certificateSANs returns the subject alternative names of a certificate
+func certificateSANs(cert *x509.Certificate) []string {
+	sans := append([]string(nil), cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	return sans
+}
+
+// publicKeyType describes the key of a certificate, e.g. "RSA 2048"
+func publicKeyType(cert *x509.Certificate) string {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA " + key.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	}
+	return cert.PublicKeyAlgorithm.String()
+}
+
+// isSelfSigned reports whether a certificate is its own issuer and signed
+// with its own key. CheckSignatureFrom is not used: it rejects self-signed
+// leaf certificates, which are not CAs.
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawSubject, cert.RawIssuer) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// tlsSummary is the one-line description of a TLS service shown by the
+// text reports
+func tlsSummary(t *TLSInfo) string {
+	name := t.Subject
+	if len(t.SANs) > 0 {
+		name = strings.Join(t.SANs, ", ")
+	}
+	summary := fmt.Sprintf("%s %s, %s, expires %s", t.Version, t.CipherSuite, name, t.NotAfter.Format("2006-01-02"))
+	if issues := t.Issues(); len(issues) > 0 {
+		summary += " ⚠️  " + strings.Join(issues, ", ")
+	}
+	return summary
+}
@@ -0,0 +1,174 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCertificate generates a self-signed ECDSA certificate valid from
+// notBefore to notAfter
+func testCertificate(t *testing.T, notBefore, notAfter time.Time) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "scan.test"},
+		DNSNames:     []string{"scan.test", "www.scan.test"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// serveTLS accepts TLS connections on a loopback port and hands each one
+// to handle once the handshake is done
+func serveTLS(t *testing.T, cert tls.Certificate, handle func(net.Conn)) int {
+	t.Helper()
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(2 * time.Second))
+				if conn.(*tls.Conn).Handshake() == nil {
+					handle(conn)
+				}
+			}()
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestInspectTLS(t *testing.T) {
+	now := time.Now()
+	port := serveTLS(t, testCertificate(t, now.Add(-time.Hour), now.AddDate(1, 0, 0)), func(net.Conn) {})
+
+	info, err := InspectTLS(context.Background(), "127.0.0.1", port, time.Second)
+	if err != nil {
+		t.Fatalf("InspectTLS: %v", err)
+	}
+	if info.Version != "TLS 1.3" || !strings.HasPrefix(info.CipherSuite, "TLS_") {
+		t.Errorf("negotiated %s %s, want TLS 1.3", info.Version, info.CipherSuite)
+	}
+	if info.Subject != "CN=scan.test" || info.Issuer != "CN=scan.test" {
+		t.Errorf("subject %q issuer %q, want CN=scan.test", info.Subject, info.Issuer)
+	}
+	if want := []string{"scan.test", "www.scan.test", "127.0.0.1"}; !reflect.DeepEqual(info.SANs, want) {
+		t.Errorf("SANs = %v, want %v", info.SANs, want)
+	}
+	if info.KeyType != "ECDSA P-256" || info.SignatureAlgorithm != "ECDSA-SHA256" {
+		t.Errorf("key %q signature %q", info.KeyType, info.SignatureAlgorithm)
+	}
+	if info.Expired || !info.SelfSigned || len(info.Weaknesses) != 0 {
+		t.Errorf("expired=%v selfSigned=%v weaknesses=%v, want only self-signed", info.Expired, info.SelfSigned, info.Weaknesses)
+	}
+	if got := info.Issues(); !reflect.DeepEqual(got, []string{"self-signed"}) {
+		t.Errorf("Issues() = %v", got)
+	}
+}
+
+func TestInspectTLSExpired(t *testing.T) {
+	now := time.Now()
+	port := serveTLS(t, testCertificate(t, now.AddDate(-2, 0, 0), now.AddDate(0, -1, 0)), func(net.Conn) {})
+
+	info, err := InspectTLS(context.Background(), "127.0.0.1", port, time.Second)
+	if err != nil {
+		t.Fatalf("InspectTLS: %v", err)
+	}
+	if !info.Expired {
+		t.Errorf("certificate expired on %v not flagged", info.NotAfter)
+	}
+	if summary := tlsSummary(info); !strings.Contains(summary, "expired, self-signed") {
+		t.Errorf("tlsSummary = %q, want the issues", summary)
+	}
+}
+
+func TestInspectTLSPlainPort(t *testing.T) {
+	port := serveTCP(t, func(conn net.Conn) {
+		conn.Write([]byte("HTTP/1.1 400 Bad Request\r\n\r\n"))
+	})
+	if info, err := InspectTLS(context.Background(), "127.0.0.1", port, time.Second); err == nil {
+		t.Errorf("InspectTLS on a plain port = %+v, want an error", info)
+	}
+}
+
+func TestTLSWeaknesses(t *testing.T) {
+	now := time.Now()
+	cert := &x509.Certificate{
+		PublicKey:          &rsa.PublicKey{N: new(big.Int).Lsh(big.NewInt(1), 1023), E: 65537},
+		SignatureAlgorithm: x509.SHA1WithRSA,
+		NotBefore:          now.Add(time.Hour),
+		NotAfter:           now.AddDate(1, 0, 0),
+	}
+	got := tlsWeaknesses(tls.VersionTLS10, tls.TLS_RSA_WITH_RC4_128_SHA, cert, now)
+	want := []string{
+		"weak protocol TLS 1.0",
+		"weak cipher TLS_RSA_WITH_RC4_128_SHA",
+		"weak key RSA 1024",
+		"weak signature SHA1-RSA",
+		"not yet valid",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tlsWeaknesses = %q, want %q", got, want)
+	}
+
+	cert.PublicKey = &rsa.PublicKey{N: new(big.Int).Lsh(big.NewInt(1), 2047), E: 65537}
+	cert.SignatureAlgorithm = x509.SHA256WithRSA
+	cert.NotBefore = now.Add(-time.Hour)
+	if got := tlsWeaknesses(tls.VersionTLS13, tls.TLS_AES_128_GCM_SHA256, cert, now); len(got) != 0 {
+		t.Errorf("tlsWeaknesses of a strong setup = %q, want none", got)
+	}
+}
+
+func TestServiceProberTLS(t *testing.T) {
+	now := time.Now()
+	port := serveTLS(t, testCertificate(t, now.Add(-time.Hour), now.AddDate(1, 0, 0)), func(conn net.Conn) {
+		request, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil || !strings.HasPrefix(request, "GET / ") {
+			return
+		}
+		conn.Write([]byte("HTTP/1.0 200 OK\r\nServer: nginx/1.24.0\r\nContent-Length: 0\r\n\r\n"))
+	})
+
+	result := ServiceProber{Timeout: 300 * time.Millisecond}.Probe(context.Background(), "127.0.0.1", port)
+	if result.TLS == nil || result.TLS.Subject != "CN=scan.test" {
+		t.Fatalf("TLS = %+v, want the certificate of the listener", result.TLS)
+	}
+	if result.Service != "http" || result.Product != "nginx" || serviceLabel(result) != "ssl/http" {
+		t.Errorf("got service %q product %q, want nginx over ssl/http", result.Service, result.Product)
+	}
+	if details := portDetails(result); len(details) == 0 || !strings.Contains(details[0], "scan.test, www.scan.test") {
+		t.Errorf("portDetails = %q, want the TLS summary", details)
+	}
+}